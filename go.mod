@@ -12,6 +12,6 @@ require (
 )
 
 require (
-	github.com/gorilla/websocket v1.4.2 // indirect
+	github.com/gorilla/websocket v1.4.2
 	github.com/mark3labs/mcp-go v0.17.0
 )
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/shawnzhang/slack-go/pkg/events"
+	"github.com/shawnzhang/slack-go/pkg/fakeslack"
+)
+
+// eventsResult is the output of slack_wait_for_events
+type eventsResult struct {
+	Events []events.Event `json:"events"`
+	Cursor int64          `json:"cursor"`
+}
+
+func TestWaitForEvents(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- events.NewSocketMode(fakeslack.AppToken, env.fake.URL, env.events).Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-stopped; err != nil {
+			t.Errorf("socket mode stopped with %v", err)
+		}
+	})
+
+	// the event waits at the fake until the listener connects
+	messageTS := "1740900000.000100"
+	eventID := env.fake.SendEvent(map[string]any{
+		"type": "message", "channel": generalID, "channel_type": "channel", "user": aliceID,
+		"text": "hi <@" + bobID + ">", "ts": messageTS, "event_ts": messageTS,
+	})
+	var got eventsResult
+	env.callJSON(t, "slack_wait_for_events", map[string]any{"channel": "general", "timeout_seconds": 5}, &got)
+	want := events.Event{
+		Cursor: 1, ID: eventID, Type: "message", TeamID: fakeslack.TeamID, Channel: generalID, User: aliceID,
+		Text: "hi @bob", TS: messageTS, Time: "2025-03-02T07:20:00Z",
+	}
+	if len(got.Events) != 1 || got.Events[0] != want || got.Cursor != 1 {
+		t.Fatalf("events = %+v, cursor %d; want %+v", got.Events, got.Cursor, want)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Contains(env.fake.Acks(), "envelope-1") {
+		if time.Now().After(deadline) {
+			t.Fatalf("envelope not acknowledged: %v", env.fake.Acks())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// waiting after the cursor returns the next event once it arrives
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		env.fake.SendEvent(map[string]any{
+			"type": "reaction_added", "user": bobID, "reaction": "eyes", "item_user": aliceID,
+			"item":     map[string]any{"type": "message", "channel": generalID, "ts": messageTS},
+			"event_ts": "1740900060.000100",
+		})
+	}()
+	start := time.Now()
+	var next eventsResult
+	env.callJSON(t, "slack_wait_for_events", map[string]any{"after": got.Cursor, "timeout_seconds": 5}, &next)
+	if len(next.Events) != 1 || next.Events[0].Type != "reaction_added" || next.Events[0].Reaction != "eyes" ||
+		next.Events[0].TS != messageTS || next.Cursor != 2 {
+		t.Errorf("next events = %+v, cursor %d", next.Events, next.Cursor)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("returned after %s, before the event was sent", elapsed)
+	}
+
+	var filtered eventsResult
+	env.callJSON(t, "slack_wait_for_events", map[string]any{"types": []any{"message"}, "user": "@bob", "timeout_seconds": 0}, &filtered)
+	if len(filtered.Events) != 0 || filtered.Cursor != 2 {
+		t.Errorf("messages by bob = %+v, cursor %d; want none and 2", filtered.Events, filtered.Cursor)
+	}
+	env.callJSON(t, "slack_wait_for_events", map[string]any{"types": []any{"reaction_added"}, "thread_ts": messageTS, "timeout_seconds": 0}, &filtered)
+	if len(filtered.Events) != 1 || filtered.Events[0].Cursor != 2 {
+		t.Errorf("reactions on the message = %+v", filtered.Events)
+	}
+	env.callJSON(t, "slack_wait_for_events", map[string]any{"after": next.Cursor, "timeout_seconds": 0.1}, &filtered)
+	if len(filtered.Events) != 0 || filtered.Cursor != 2 {
+		t.Errorf("wait that timed out = %+v, cursor %d; want none and 2", filtered.Events, filtered.Cursor)
+	}
+
+	for _, arguments := range []map[string]any{
+		{"types": []any{"pin_added"}},
+		{"timeout_seconds": 301},
+		{"timeout_seconds": 300.5},
+		{"timeout_seconds": 1e12},
+		{"timeout_seconds": -1},
+		{"limit": 0},
+	} {
+		if text, isError := env.call(t, "slack_wait_for_events", arguments); !isError {
+			t.Errorf("%v accepted: %s", arguments, text)
+		}
+	}
+}
+
+func TestEventsAPI(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+	receiver := httptest.NewServer(events.NewReceiver(fakeslack.SigningSecret, env.events))
+	t.Cleanup(receiver.Close)
+
+	messageTS := "1740900000.000100"
+	payload := env.fake.EventCallback(map[string]any{
+		"type": "app_mention", "channel": generalID, "user": aliceID,
+		"text": "<@" + bobID + "> ping", "ts": messageTS, "event_ts": messageTS,
+	})
+	send := func(secret string, retry bool) int {
+		t.Helper()
+		req, err := fakeslack.EventRequest(receiver.URL, secret, payload, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if retry {
+			req.Header.Set("X-Slack-Retry-Num", "1")
+			req.Header.Set("X-Slack-Retry-Reason", "http_timeout")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := send("wrong-secret", false); code != http.StatusUnauthorized {
+		t.Errorf("request signed with the wrong secret answered %d, want 401", code)
+	}
+	if code := send(fakeslack.SigningSecret, false); code != http.StatusOK {
+		t.Errorf("event answered %d, want 200", code)
+	}
+	if code := send(fakeslack.SigningSecret, true); code != http.StatusOK {
+		t.Errorf("redelivery answered %d, want 200", code)
+	}
+
+	var got eventsResult
+	env.callJSON(t, "slack_wait_for_events", map[string]any{"types": []any{"app_mention"}, "timeout_seconds": 0}, &got)
+	if len(got.Events) != 1 || got.Events[0].ID != payload["event_id"] || got.Events[0].Text != "@bob ping" || got.Cursor != 1 {
+		t.Errorf("events = %+v, cursor %d; want the mention once", got.Events, got.Cursor)
+	}
+}
+
+func TestEventsWakeSubscriptions(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+	// polling alone would notice the new reply only after an hour
+	subscriptions := newResourceSubscriptions(env.workspaces, time.Hour)
+	t.Cleanup(subscriptions.Close)
+	env.events.Listen(subscriptions.eventReceived)
+
+	updates := make(chan string, 10)
+	notify := func(notification mcp.JSONRPCNotification) error {
+		updates <- notification.Params.AdditionalFields["uri"].(string)
+		return nil
+	}
+	threadURI := "slack://thread/" + generalID + "/" + env.threadTS
+	if err := subscriptions.Subscribe(context.Background(), "session", threadURI, notify); err != nil {
+		t.Fatal(err)
+	}
+
+	// events that leave the thread unchanged are checked without a notification
+	env.events.Add(events.Event{Type: "reaction_added", TeamID: fakeslack.TeamID, Channel: generalID, TS: env.threadTS})
+	env.events.Add(events.Event{Type: "message", TeamID: fakeslack.TeamID, Channel: generalID, TS: env.helloTS})
+	replyTS := env.fake.AddMessage(generalID, message(aliceID, "rolled back", env.threadTS))
+	env.events.Add(events.Event{Type: "message", TeamID: fakeslack.TeamID, Channel: generalID, TS: replyTS, ThreadTS: env.threadTS})
+
+	select {
+	case uri := <-updates:
+		if uri != threadURI {
+			t.Errorf("update for %s, want %s", uri, threadURI)
+		}
+	case <-time.After(notificationTimeout):
+		t.Fatal("no update after the reply's event")
+	}
+	select {
+	case uri := <-updates:
+		t.Errorf("second update for %s", uri)
+	case <-time.After(10 * testPollInterval):
+	}
+}
@@ -3,43 +3,222 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	// embeds the time zone database, so profile time zones resolve in images without /usr/share/zoneinfo
+	_ "time/tzdata"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/shawnzhang/slack-go/pkg/events"
+	"github.com/shawnzhang/slack-go/pkg/mrkdwn"
 	"github.com/shawnzhang/slack-go/pkg/slack"
+	"github.com/shawnzhang/slack-go/pkg/transport"
+	"github.com/shawnzhang/slack-go/pkg/workspace"
 )
 
+// toolScopes are the OAuth scopes each tool needs. A tool is registered when at least one
+// workspace's token grants them; tools not listed need no scope.
+var toolScopes = map[string][]workspace.Requirement{
+	"slack_list_channels":            {{"channels:read"}},
+	"slack_get_thread_replies":       {{"channels:history", "groups:history", "im:history", "mpim:history"}},
+	"post_message":                   {{"chat:write"}},
+	"slack_get_users_profile":        {{"users:read"}},
+	"slack_get_channel_history":      {{"channels:history", "groups:history", "im:history", "mpim:history"}},
+	"slack_reply_to_thread":          {{"chat:write"}},
+	"slack_add_reaction":             {{"reactions:write"}},
+	"slack_remove_reaction":          {{"reactions:write"}},
+	"slack_get_reactions":            {{"reactions:read"}},
+	"slack_search_messages":          {{"search:read"}},
+	"slack_list_users":               {{"users:read"}},
+	"slack_find_user":                {{"users:read"}},
+	"slack_find_channel":             {{"channels:read", "groups:read", "im:read", "mpim:read"}},
+	"slack_schedule_message":         {{"chat:write"}},
+	"slack_delete_scheduled_message": {{"chat:write"}},
+}
+
+// toolTimeouts are the timeouts of tools that may page through a whole directory, which
+// Slack's rate limits can stretch past the default; -tool-timeouts overrides them
+var toolTimeouts = map[string]time.Duration{
+	"slack_find_user":       3 * time.Minute,
+	"slack_find_channel":    3 * time.Minute,
+	"slack_wait_for_events": maxEventWait + time.Minute,
+}
+
+// maxEventWait caps how long slack_wait_for_events waits for an event
+const maxEventWait = 5 * time.Minute
+
 func main() {
 	// set log output to stderr
 	log.SetOutput(os.Stderr)
 	log.SetFlags(log.LstdFlags | log.Lmicroseconds)
 
+	transportName := flag.String("transport", envOrDefault("MCP_TRANSPORT", transport.Stdio),
+		"transport to serve MCP over: stdio, sse or streamable-http (env MCP_TRANSPORT)")
+	listenAddr := flag.String("listen", envOrDefault("MCP_LISTEN_ADDR", "127.0.0.1:8080"),
+		"listen address of the sse and streamable-http transports (env MCP_LISTEN_ADDR)")
+	basePath := flag.String("base-path", os.Getenv("MCP_BASE_PATH"),
+		"path prefix of the HTTP endpoints, e.g. /slack (env MCP_BASE_PATH)")
+	workspacesFile := flag.String("workspaces", os.Getenv("SLACK_WORKSPACES_FILE"),
+		"JSON file mapping workspace aliases to tokens and team IDs; without it SLACK_TOKEN and SLACK_TEAM_ID are used (env SLACK_WORKSPACES_FILE)")
+	toolTimeout := flag.Duration("tool-timeout", durationEnv("MCP_TOOL_TIMEOUT", time.Minute),
+		"how long a tool call may take before its Slack calls are aborted (env MCP_TOOL_TIMEOUT)")
+	toolTimeoutOverrides := flag.String("tool-timeouts", os.Getenv("MCP_TOOL_TIMEOUTS"),
+		"per-tool timeouts overriding -tool-timeout, e.g. slack_search_messages=30s,slack_find_user=5m (env MCP_TOOL_TIMEOUTS)")
+	pollInterval := flag.Duration("resource-poll-interval", durationEnv("MCP_RESOURCE_POLL_INTERVAL", 30*time.Second),
+		"how often subscribed threads and channel histories are checked for new messages (env MCP_RESOURCE_POLL_INTERVAL)")
+	eventBufferSize := flag.Int("event-buffer-size", intEnv("MCP_EVENT_BUFFER_SIZE", 100),
+		"how many of the latest events received over Socket Mode are kept per channel (env MCP_EVENT_BUFFER_SIZE)")
+	flag.Parse()
+
+	if *pollInterval <= 0 {
+		log.Fatalf("invalid -resource-poll-interval %s: must be positive", *pollInterval)
+	}
+	if *eventBufferSize <= 0 {
+		log.Fatalf("invalid -event-buffer-size %d: must be positive", *eventBufferSize)
+	}
+	if err := parseToolTimeouts(*toolTimeoutOverrides, toolTimeouts); err != nil {
+		log.Fatalf("invalid -tool-timeouts: %v", err)
+	}
+
+	// the bearer token is read from the environment only, so it does not show up in process listings
+	authToken := os.Getenv("MCP_AUTH_TOKEN")
+	switch *transportName {
+	case transport.Stdio:
+		if os.Getenv("SLACK_SIGNING_SECRET") != "" {
+			log.Fatalf("SLACK_SIGNING_SECRET needs the sse or streamable-http transport to serve the Events API endpoint")
+		}
+	case transport.SSE, transport.StreamableHTTP:
+		if authToken == "" && !transport.IsLoopback(*listenAddr) {
+			log.Fatalf("please set MCP_AUTH_TOKEN to serve %s on %s; only loopback addresses may run without authentication", *transportName, *listenAddr)
+		}
+	default:
+		log.Fatalf("unknown transport %q: must be one of stdio, sse, streamable-http", *transportName)
+	}
+
 	log.Printf("start slack-go MCP server...")
 
-	// init slack client
-	token := os.Getenv("SLACK_TOKEN")
-	if token == "" {
-		token = os.Getenv("SLACK_BOT_TOKEN")
+	// stop gracefully on SIGTERM or SIGINT
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	// init slack clients, one per workspace, each checked with auth.test
+	config, err := workspaceConfig(*workspacesFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	workspaces, err := workspace.NewRegistry(ctx, config)
+	if err != nil {
+		log.Fatalf("failed to set up workspaces: %v", err)
+	}
+	for _, ws := range workspaces.List() {
+		log.Printf("workspace %s: team %s (%s) as %s, %s token, default=%t",
+			ws.Alias, ws.TeamName, ws.TeamID, ws.User, ws.TokenType, ws.Default)
+		if ws.Identity.ScopesKnown {
+			log.Printf("workspace %s scopes: %s", ws.Alias, strings.Join(ws.Identity.Scopes, ","))
+		} else {
+			log.Printf("workspace %s: Slack reported no scopes, every tool will be registered", ws.Alias)
+		}
 	}
-	teamID := os.Getenv("SLACK_TEAM_ID")
 
-	if token == "" || teamID == "" {
-		log.Fatal("please set SLACK_TOKEN (or SLACK_BOT_TOKEN) and SLACK_TEAM_ID environment variables")
+	// receive events over Socket Mode when an app-level token is set, and over the Events API
+	// when a signing secret is; like MCP_AUTH_TOKEN both are read from the environment only
+	var eventBuffer *events.Buffer
+	appToken, signingSecret := os.Getenv("SLACK_APP_TOKEN"), os.Getenv("SLACK_SIGNING_SECRET")
+	if appToken != "" || signingSecret != "" {
+		eventBuffer = events.NewBuffer(*eventBufferSize)
+	}
+	if appToken != "" {
+		listener := events.NewSocketMode(appToken, defaultAPIURL(config, workspaces), eventBuffer)
+		go func() {
+			if err := listener.Run(ctx); err != nil {
+				log.Printf("socket mode stopped: %v", err)
+			}
+		}()
+	}
+
+	s, err := newServer(workspaces, *toolTimeout, toolTimeouts, eventBuffer)
+	if err != nil {
+		log.Fatalf("invalid -tool-timeouts: %v", err)
+	}
+	subscriptions := newResourceSubscriptions(workspaces, *pollInterval)
+	defer subscriptions.Close()
+	if eventBuffer != nil {
+		eventBuffer.Listen(subscriptions.eventReceived)
+	}
+
+	if *transportName == transport.Stdio {
+		// start standard input/output server
+		log.Printf("MCP server is ready, start to process requests...")
+		if err := transport.ServeStdio(ctx, s, subscriptions, os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("Server error: %v", err)
+		}
+		log.Printf("MCP server stopped")
+		return
 	}
 
-	slackClient := slack.NewClient(token)
+	// start HTTP server
+	var eventsReceiver http.Handler
+	if signingSecret != "" {
+		eventsReceiver = events.NewReceiver(signingSecret, eventBuffer)
+	}
+	err = transport.ServeHTTP(ctx, s, transport.Config{
+		Transport:     *transportName,
+		Addr:          *listenAddr,
+		BasePath:      *basePath,
+		AuthToken:     authToken,
+		Subscriptions: subscriptions,
+		Events:        eventsReceiver,
+	})
+	if err != nil {
+		log.Fatalf("Server error: %v", err)
+	}
+	log.Printf("MCP server stopped")
+}
 
+// newServer creates the MCP server with the tools, resources and prompts the workspaces' tokens allow.
+// Tool calls time out after toolTimeout, or the timeout timeouts names for the tool.
+// slack_wait_for_events reads eventBuffer and is left out when it is nil.
+func newServer(workspaces *workspace.Registry, toolTimeout time.Duration, timeouts map[string]time.Duration, eventBuffer *events.Buffer) (*server.MCPServer, error) {
 	// Create a new MCP server
 	s := server.NewMCPServer(
 		"slack-go",
 		"1.0.0",
 		server.WithResourceCapabilities(true, true),
+		server.WithPromptCapabilities(false),
 		server.WithLogging(),
 	)
 
+	// addTool registers a tool unless no workspace grants the scopes it needs. The handler
+	// runs with the tool's timeout; its Slack calls end when it expires or the client cancels.
+	toolNames := make(map[string]bool)
+	addTool := func(tool mcp.Tool, handler server.ToolHandlerFunc) {
+		toolNames[tool.Name] = true
+		if !workspaces.AnyGrants(toolScopes[tool.Name]) {
+			log.Printf("skip tool %s: no workspace grants %s", tool.Name, requirementsString(toolScopes[tool.Name]))
+			return
+		}
+		timeout, ok := timeouts[tool.Name]
+		if !ok {
+			timeout = toolTimeout
+		}
+		s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return handler(ctx, request)
+		})
+	}
+
 	// define tools: slack_list_channels
 	listChannelsTool := mcp.NewTool("slack_list_channels",
 		mcp.WithDescription("list public channels in the workspace (supports pagination)"),
@@ -50,6 +229,14 @@ func main() {
 		mcp.WithString("cursor",
 			mcp.Description("the pagination cursor for the next page results"),
 		),
+		mcp.WithBoolean("include_archived",
+			mcp.Description("include archived channels"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithString("name_prefix",
+			mcp.Description("only channels whose name starts with this prefix"),
+		),
+		workspaceOption(),
 	)
 
 	// define tools: slack_get_thread_replies
@@ -57,8 +244,14 @@ func main() {
 		mcp.WithDescription("get all replies in a message thread"),
 		mcp.WithString("thread_url",
 			mcp.Required(),
-			mcp.Description("Slack message URL"),
+			mcp.Description("Slack message URL of the thread's parent message or of any reply in it"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("return the maximum number of messages, following pagination as needed (default 1000)"),
+			mcp.DefaultNumber(1000),
 		),
+		outputFormatOption(),
+		workspaceOption(),
 	)
 
 	// define tools: postMessageTool
@@ -66,12 +259,36 @@ func main() {
 		mcp.WithDescription("post a message to a Slack channel"),
 		mcp.WithString("channel_id",
 			mcp.Required(),
-			mcp.Description("ID of the channel to post the message to"),
+			mcp.Description("channel to post the message to: ID, #name, name or @user for a direct message"),
 		),
 		mcp.WithString("text",
-			mcp.Required(),
-			mcp.Description("Text of the message to post"),
+			mcp.Description("Text of the message to post; required unless blocks or attachments are given, where it becomes the notification fallback"),
+		),
+		mcp.WithArray("blocks",
+			mcp.Description("Block Kit blocks as a JSON array (e.g. [{\"type\":\"section\",\"text\":{\"type\":\"mrkdwn\",\"text\":\"*hi*\"}}]); a JSON string is also accepted"),
+			mcp.Items(map[string]interface{}{"type": "object"}),
+		),
+		mcp.WithArray("attachments",
+			mcp.Description("legacy message attachments as a JSON array; a JSON string is also accepted"),
+			mcp.Items(map[string]interface{}{"type": "object"}),
+		),
+		mcp.WithBoolean("unfurl_links",
+			mcp.Description("unfurl text-based links (Slack default when omitted)"),
 		),
+		mcp.WithBoolean("unfurl_media",
+			mcp.Description("unfurl media links (Slack default when omitted)"),
+		),
+		mcp.WithBoolean("mrkdwn",
+			mcp.Description("format text as mrkdwn (default true); false sends it literally"),
+		),
+		mcp.WithString("username",
+			mcp.Description("override the bot's display name for this message (requires chat:write.customize)"),
+		),
+		mcp.WithString("icon_emoji",
+			mcp.Description("override the bot's icon with an emoji, e.g. :robot_face: (requires chat:write.customize)"),
+		),
+		messageFormatOption(),
+		workspaceOption(),
 	)
 
 	// define tools: slack_get_users_profile
@@ -79,12 +296,303 @@ func main() {
 		mcp.WithDescription("get multiple users' profile information"),
 		mcp.WithArray("user_ids",
 			mcp.Required(),
-			mcp.Description("Array of user IDs to get profiles for"),
+			mcp.Description("Array of users to get profiles for: user IDs, emails, @handles or names"),
+		),
+		workspaceOption(),
+	)
+
+	// define tools: slack_get_channel_history
+	getChannelHistoryTool := mcp.NewTool("slack_get_channel_history",
+		mcp.WithDescription("get the message history of a channel within an optional time window (supports pagination)"),
+		mcp.WithString("channel_id",
+			mcp.Required(),
+			mcp.Description("channel to read: ID, #name, name or @user for a direct message"),
+		),
+		mcp.WithString("oldest",
+			mcp.Description("only messages after this time: Slack timestamp, RFC 3339 time or YYYY-MM-DD date"),
+		),
+		mcp.WithString("latest",
+			mcp.Description("only messages before this time: Slack timestamp, RFC 3339 time or YYYY-MM-DD date"),
+		),
+		mcp.WithBoolean("inclusive",
+			mcp.Description("include messages exactly at oldest or latest"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("return the maximum number of messages (default 100, max 999)"),
+			mcp.DefaultNumber(100),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("the pagination cursor for the next page results"),
+		),
+		outputFormatOption(),
+		workspaceOption(),
+	)
+
+	// define tools: slack_reply_to_thread
+	replyToThreadTool := mcp.NewTool("slack_reply_to_thread",
+		mcp.WithDescription("reply to an existing message thread, identified by channel_id + thread_ts or by thread_url"),
+		mcp.WithString("channel_id",
+			mcp.Description("ID of the channel containing the thread (required unless thread_url is given)"),
+		),
+		mcp.WithString("thread_ts",
+			mcp.Description("timestamp of the parent message of the thread (required unless thread_url is given)"),
+		),
+		mcp.WithString("thread_url",
+			mcp.Description("Slack message URL of the thread, used instead of channel_id + thread_ts"),
+		),
+		mcp.WithString("text",
+			mcp.Required(),
+			mcp.Description("Text of the reply to post"),
+		),
+		mcp.WithBoolean("reply_broadcast",
+			mcp.Description("also send the reply to the channel"),
+			mcp.DefaultBool(false),
+		),
+		messageFormatOption(),
+		workspaceOption(),
+	)
+
+	// define tools: slack_schedule_message, slack_list_scheduled_messages, slack_delete_scheduled_message
+	scheduleMessageTool := mcp.NewTool("slack_schedule_message",
+		mcp.WithDescription("schedule a message for Slack to post later, e.g. tomorrow at 9am, at most 120 days ahead"),
+		mcp.WithString("channel_id",
+			mcp.Required(),
+			mcp.Description("channel to post the message to: ID, #name, name or @user for a direct message"),
+		),
+		mcp.WithString("post_at",
+			mcp.Required(),
+			mcp.Description("when to post: an ISO 8601 time, read in timezone unless it has an offset (2025-03-02T09:00, 2025-03-02T09:00:00+08:00), "+
+				"today or tomorrow at a time of day (tomorrow 09:00, tomorrow 9am) or a duration from now (in 2h, 90m, 3d)"),
+		),
+		mcp.WithString("timezone",
+			mcp.Description("IANA time zone of post_at, e.g. Europe/Berlin; defaults to the profile time zone of the direct message's recipient, "+
+				"otherwise of the user the server acts as, otherwise UTC"),
+		),
+		mcp.WithString("text",
+			mcp.Description("Text of the message; required unless blocks are given, where it becomes the notification fallback"),
+		),
+		mcp.WithArray("blocks",
+			mcp.Description("Block Kit blocks as a JSON array; a JSON string is also accepted"),
+			mcp.Items(map[string]interface{}{"type": "object"}),
+		),
+		mcp.WithString("thread_ts",
+			mcp.Description("timestamp of a thread's parent message to post the message as a reply"),
+		),
+		mcp.WithBoolean("reply_broadcast",
+			mcp.Description("also send the reply to the channel"),
+			mcp.DefaultBool(false),
+		),
+		messageFormatOption(),
+		workspaceOption(),
+	)
+
+	listScheduledMessagesTool := mcp.NewTool("slack_list_scheduled_messages",
+		mcp.WithDescription("list the messages this app has scheduled and Slack has not posted yet (supports pagination)"),
+		mcp.WithString("channel_id",
+			mcp.Description("only messages scheduled in this channel: ID, #name, name or @user"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("return the maximum number of messages (default 100, max 100)"),
+			mcp.DefaultNumber(100),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("the pagination cursor for the next page results"),
+		),
+		workspaceOption(),
+	)
+
+	deleteScheduledMessageTool := mcp.NewTool("slack_delete_scheduled_message",
+		mcp.WithDescription("cancel a scheduled message before Slack posts it"),
+		mcp.WithString("channel_id",
+			mcp.Required(),
+			mcp.Description("channel the message is scheduled in: ID, #name, name or @user"),
+		),
+		mcp.WithString("scheduled_message_id",
+			mcp.Required(),
+			mcp.Description("ID returned by slack_schedule_message or slack_list_scheduled_messages, e.g. Q1298393284"),
+		),
+		workspaceOption(),
+	)
+
+	// define tools: slack_add_reaction, slack_remove_reaction, slack_get_reactions
+	reactionTargetOptions := []mcp.ToolOption{
+		mcp.WithString("channel_id",
+			mcp.Description("ID of the channel containing the message (required unless message_url is given)"),
+		),
+		mcp.WithString("timestamp",
+			mcp.Description("timestamp of the message (required unless message_url is given)"),
+		),
+		mcp.WithString("message_url",
+			mcp.Description("Slack message URL, used instead of channel_id + timestamp"),
+		),
+		workspaceOption(),
+	}
+	reactionNameOption := mcp.WithString("reaction",
+		mcp.Required(),
+		mcp.Description("emoji name, with or without colons (e.g. eyes, :white_check_mark:, :thumbsup::skin-tone-2:)"),
+	)
+
+	addReactionTool := mcp.NewTool("slack_add_reaction",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("add an emoji reaction to a message"),
+			reactionNameOption,
+		}, reactionTargetOptions...)...,
+	)
+
+	removeReactionTool := mcp.NewTool("slack_remove_reaction",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("remove an emoji reaction previously added to a message"),
+			reactionNameOption,
+		}, reactionTargetOptions...)...,
+	)
+
+	getReactionsTool := mcp.NewTool("slack_get_reactions",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("get the emoji reactions on a message with their counts and the users who added them"),
+		}, reactionTargetOptions...)...,
+	)
+
+	// define tools: slack_search_messages
+	searchMessagesTool := mcp.NewTool("slack_search_messages",
+		mcp.WithDescription("search messages across the workspace (requires a user token, xoxp-)"),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("search query; supports modifiers such as in:#channel, from:@user, before:2025-03-01, after:2025-02-01, has:link"),
+		),
+		mcp.WithString("sort",
+			mcp.Description("sort results by relevance score or by timestamp"),
+			mcp.Enum("score", "timestamp"),
+			mcp.DefaultString("score"),
+		),
+		mcp.WithString("sort_dir",
+			mcp.Description("sort direction"),
+			mcp.Enum("asc", "desc"),
+			mcp.DefaultString("desc"),
+		),
+		mcp.WithNumber("count",
+			mcp.Description("return the maximum number of matches per page (default 20, max 100)"),
+			mcp.DefaultNumber(20),
+		),
+		mcp.WithNumber("page",
+			mcp.Description("page number of the results, starting at 1"),
+			mcp.DefaultNumber(1),
+		),
+		outputFormatOption(),
+		workspaceOption(),
+	)
+
+	// define tools: slack_list_users
+	listUsersTool := mcp.NewTool("slack_list_users",
+		mcp.WithDescription("list users in the workspace directory (supports pagination)"),
+		mcp.WithNumber("limit",
+			mcp.Description("the number of users Slack scans per page before filters apply (default 100, max 200)"),
+			mcp.DefaultNumber(100),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("the pagination cursor for the next page results"),
+		),
+		mcp.WithBoolean("include_deleted",
+			mcp.Description("include deactivated users"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithBoolean("include_bots",
+			mcp.Description("include bot and app users"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithBoolean("include_guests",
+			mcp.Description("include single- and multi-channel guests"),
+			mcp.DefaultBool(true),
+		),
+		mcp.WithString("tz",
+			mcp.Description("only users in this IANA time zone (e.g. Asia/Singapore)"),
+		),
+		workspaceOption(),
+	)
+
+	// define tools: slack_find_user
+	findUserTool := mcp.NewTool("slack_find_user",
+		mcp.WithDescription("find users by email, @handle, display name or real name, ranked by confidence"),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("what you know about the user, e.g. alice@corp.com, @alice, Alice Smith"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("return the maximum number of candidates (default 5)"),
+			mcp.DefaultNumber(5),
+		),
+		workspaceOption(),
+	)
+
+	// define tools: slack_find_channel
+	findChannelTool := mcp.NewTool("slack_find_channel",
+		mcp.WithDescription("find conversations by #name, name, ID, permalink or @user (for direct messages) and return their IDs"),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("what you know about the channel, e.g. #general, general, C0123ABCDEF, @alice"),
+		),
+		mcp.WithArray("types",
+			mcp.Description("conversation types to search (default all): public_channel, private_channel, mpim, im"),
+			mcp.Items(map[string]interface{}{
+				"type": "string",
+				"enum": slack.AllConversationTypes,
+			}),
+		),
+		workspaceOption(),
+	)
+
+	// define tools: slack_list_workspaces
+	listWorkspacesTool := mcp.NewTool("slack_list_workspaces",
+		mcp.WithDescription("list the Slack workspaces this server can act in; pass an alias as the workspace argument of other tools"),
+	)
+
+	// define tools: slack_whoami
+	whoamiTool := mcp.NewTool("slack_whoami",
+		mcp.WithDescription("show who the server acts as in a workspace: user, team, token type, granted scopes, the scopes each tool is missing and the state of the rate-limit queues"),
+		workspaceOption(),
+	)
+
+	// define tools: slack_wait_for_events
+	waitForEventsTool := mcp.NewTool("slack_wait_for_events",
+		mcp.WithDescription("wait for live Slack events (new messages, mentions, reactions, channel changes) received over Socket Mode or the Events API; returns the matching buffered events at once, otherwise waits for the next one"),
+		mcp.WithString("channel",
+			mcp.Description("only events of this channel: ID or name, e.g. C01234567 or #general"),
+		),
+		mcp.WithArray("types",
+			mcp.Description("only events of these types (default all)"),
+			mcp.Items(map[string]interface{}{
+				"type": "string",
+				"enum": events.Types,
+			}),
+		),
+		mcp.WithString("user",
+			mcp.Description("only events by this user: ID, email or @handle"),
 		),
+		mcp.WithString("thread_ts",
+			mcp.Description("only the replies of this thread and events on its parent message"),
+		),
+		mcp.WithNumber("after",
+			mcp.Description("only events after this cursor; pass the cursor of the previous result to get the events that followed it (default 0: every buffered event)"),
+			mcp.DefaultNumber(0),
+		),
+		mcp.WithNumber("timeout_seconds",
+			mcp.Description(fmt.Sprintf("how long to wait when no buffered event matches (default 30, max %d); 0 returns at once", int(maxEventWait.Seconds()))),
+			mcp.DefaultNumber(30),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("return the maximum number of events (default 50, max 200)"),
+			mcp.DefaultNumber(50),
+		),
+		workspaceOption(),
 	)
 
 	// add tools and handle functions
-	s.AddTool(listChannelsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	addTool(listChannelsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slackClient, err := clientFor(workspaces, request)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+
 		limit := 100
 		if l, ok := request.Params.Arguments["limit"].(float64); ok {
 			limit = int(l)
@@ -99,86 +607,182 @@ func main() {
 			log.Printf("use provided cursor: %s", cursor)
 		}
 
+		params := &slack.ListChannelsParameters{
+			Limit:  limit,
+			Cursor: cursor,
+		}
+		params.IncludeArchived, _ = request.Params.Arguments["include_archived"].(bool)
+		params.NamePrefix, _ = request.Params.Arguments["name_prefix"].(string)
+
 		log.Printf("start to get channel list...")
 
 		// call slack api to get channel list
-		result, err := slackClient.ListChannels(limit, cursor)
+		result, err := slackClient.ListChannels(ctx, params)
 		if err != nil {
 			log.Printf("failed to get channel list: %v", err)
-			return nil, fmt.Errorf("failed to get channel list: %v", err)
+			return slackErrorResult(slackClient, request, "failed to get channel list", err), nil
 		}
 		log.Printf("success to get channel list")
 
-		channelsJSON, err := json.Marshal(result.Channels)
+		page := &slack.ChannelListPage{
+			Channels:   make([]*slack.ChannelInfo, 0, len(result.Channels)),
+			NextCursor: result.ResponseMetadata.NextCursor,
+		}
+		for i := range result.Channels {
+			page.Channels = append(page.Channels, slack.NewChannelInfo(&result.Channels[i]))
+		}
+
+		channelsJSON, err := json.Marshal(page)
 		if err != nil {
-			return nil, fmt.Errorf("failed to serialize channel list: %v", err)
+			return slackErrorResult(slackClient, request, "failed to serialize channel list", err), nil
 		}
 
 		return mcp.NewToolResultText(fmt.Sprintf("channel list: \n%s", string(channelsJSON))), nil
 	})
 
-	s.AddTool(getThreadRepliesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	addTool(getThreadRepliesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slackClient, err := clientFor(workspaces, request)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+
 		threadURL, ok := request.Params.Arguments["thread_url"].(string)
 		if !ok || threadURL == "" {
 			log.Printf("error: invalid thread_url: %v", request.Params.Arguments["thread_url"])
-			return nil, fmt.Errorf("thread_url is required")
+			return argumentError("thread_url is required"), nil
 		}
 		log.Printf("process thread URL: %s", threadURL)
 
+		limit := 1000
+		if l, ok := request.Params.Arguments["limit"].(float64); ok {
+			limit = int(l)
+		}
+		if limit <= 0 {
+			return argumentError("limit must be greater than 0"), nil
+		}
+		outputFormat, err := outputFormatArgument(request.Params.Arguments)
+		if err != nil {
+			return argumentError(err.Error()), nil
+		}
+
 		// call slack api to get thread replies
 		log.Printf("start to get thread replies...")
-		result, err := slackClient.GetThreadReplies(threadURL)
+		result, err := slackClient.GetThreadReplies(ctx, threadURL, limit)
 		if err != nil {
 			log.Printf("failed to get thread replies: %v", err)
-			return nil, fmt.Errorf("failed to get thread replies: %v", err)
+			return slackErrorResult(slackClient, request, "failed to get thread replies", err), nil
+		}
+		log.Printf("success to get thread replies: %d messages, truncated=%t", len(result.Messages), result.Truncated)
+
+		var output interface{} = result
+		switch outputFormat {
+		case "compact_json":
+			output = &slack.RenderedThreadReplies{
+				ChannelID: result.ChannelID,
+				ThreadTS:  result.ThreadTS,
+				Messages:  slackClient.RenderMessages(ctx, result.Messages),
+				Truncated: result.Truncated,
+			}
+		case "markdown_transcript":
+			transcript := slack.FormatTranscript(slackClient.RenderMessages(ctx, result.Messages))
+			if result.Truncated {
+				transcript += fmt.Sprintf("\n_thread truncated after %d messages; raise limit to read more_\n", len(result.Messages))
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("thread %s in channel %s:\n\n%s", result.ThreadTS, result.ChannelID, transcript)), nil
 		}
-		log.Printf("success to get thread replies")
 
-		messagesJSON, err := json.Marshal(result.Messages)
+		messagesJSON, err := json.Marshal(output)
 		if err != nil {
-			return nil, fmt.Errorf("failed to serialize thread replies: %v", err)
+			return slackErrorResult(slackClient, request, "failed to serialize thread replies", err), nil
 		}
 
 		return mcp.NewToolResultText(fmt.Sprintf("thread replies: \n%s", string(messagesJSON))), nil
 	})
 
-	s.AddTool(postMessageTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	addTool(postMessageTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slackClient, err := clientFor(workspaces, request)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+
 		channelID, ok := request.Params.Arguments["channel_id"].(string)
 		if !ok || channelID == "" {
 			log.Printf("error: invalid channel_id: %v", request.Params.Arguments["channel_id"])
-			return nil, fmt.Errorf("channel_id is required")
+			return argumentError("channel_id is required"), nil
 		}
 
-		text, ok := request.Params.Arguments["text"].(string)
-		if !ok || text == "" {
+		params := &slack.PostMessageParameters{}
+		params.Text, _ = request.Params.Arguments["text"].(string)
+
+		if data, ok, err := jsonArgument(request.Params.Arguments, "blocks"); err != nil {
+			return argumentError(err.Error()), nil
+		} else if ok {
+			if params.Blocks, err = slack.ParseBlocks(data); err != nil {
+				log.Printf("error: invalid blocks: %v", err)
+				return argumentError(fmt.Sprintf("invalid blocks: %v", err)), nil
+			}
+		}
+		if data, ok, err := jsonArgument(request.Params.Arguments, "attachments"); err != nil {
+			return argumentError(err.Error()), nil
+		} else if ok {
+			if params.Attachments, err = slack.ParseAttachments(data); err != nil {
+				log.Printf("error: invalid attachments: %v", err)
+				return argumentError(fmt.Sprintf("invalid attachments: %v", err)), nil
+			}
+		}
+
+		if params.Text == "" && len(params.Blocks) == 0 && len(params.Attachments) == 0 {
 			log.Printf("error: invalid text: %v", request.Params.Arguments["text"])
-			return nil, fmt.Errorf("text is required")
+			return argumentError("text is required unless blocks or attachments are given"), nil
+		}
+
+		params.UnfurlLinks = boolArgument(request.Params.Arguments, "unfurl_links")
+		params.UnfurlMedia = boolArgument(request.Params.Arguments, "unfurl_media")
+		params.Mrkdwn = boolArgument(request.Params.Arguments, "mrkdwn")
+		params.Username, _ = request.Params.Arguments["username"].(string)
+		params.IconEmoji, _ = request.Params.Arguments["icon_emoji"].(string)
+
+		format, _ := request.Params.Arguments["format"].(string)
+		if err := applyMessageFormat(params, format); err != nil {
+			return argumentError(err.Error()), nil
+		}
+
+		channelID, err = slackClient.ResolveChannelID(ctx, channelID)
+		if err != nil {
+			log.Printf("failed to resolve channel: %v", err)
+			return slackErrorResult(slackClient, request, "failed to resolve channel", err), nil
 		}
+		params.ChannelID = channelID
 
-		log.Printf("posting message to channel: %s", channelID)
+		log.Printf("posting message to channel: %s (%d blocks, %d attachments)", channelID, len(params.Blocks), len(params.Attachments))
 
 		// call slack api to post message
-		message, err := slackClient.PostMessage(channelID, text)
+		message, err := slackClient.PostMessage(ctx, params)
 		if err != nil {
 			log.Printf("failed to post message: %v", err)
-			return nil, fmt.Errorf("failed to post message: %v", err)
+			return slackErrorResult(slackClient, request, "failed to post message", err), nil
 		}
 		log.Printf("success to post message")
 
 		messageJSON, err := json.Marshal(message)
 		if err != nil {
-			return nil, fmt.Errorf("failed to serialize message: %v", err)
+			return slackErrorResult(slackClient, request, "failed to serialize message", err), nil
 		}
 
 		return mcp.NewToolResultText(fmt.Sprintf("message posted: \n%s", string(messageJSON))), nil
 	})
 
-	s.AddTool(getUsersProfileTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	addTool(getUsersProfileTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slackClient, err := clientFor(workspaces, request)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+
 		// 获取并验证用户ID数组
 		userIDsInterface, ok := request.Params.Arguments["user_ids"].([]interface{})
 		if !ok || len(userIDsInterface) == 0 {
 			log.Printf("error: invalid user_ids: %v", request.Params.Arguments["user_ids"])
-			return nil, fmt.Errorf("user_ids array is required and cannot be empty")
+			return argumentError("user_ids array is required and cannot be empty"), nil
 		}
 
 		// 转换interface{}数组为string数组
@@ -186,33 +790,1066 @@ func main() {
 		for i, v := range userIDsInterface {
 			userID, ok := v.(string)
 			if !ok || userID == "" {
-				return nil, fmt.Errorf("invalid user ID at position %d", i)
+				return argumentError(fmt.Sprintf("invalid user ID at position %d", i)), nil
+			}
+			// resolve emails, @handles and names to user IDs; IDs pass through unchanged
+			resolvedID, err := slackClient.ResolveUserID(ctx, userID)
+			if err != nil {
+				log.Printf("failed to resolve user %q: %v", userID, err)
+				return slackErrorResult(slackClient, request, fmt.Sprintf("failed to resolve user at position %d", i), err), nil
 			}
-			userIDs[i] = userID
+			userIDs[i] = resolvedID
 		}
 
 		log.Printf("getting profiles for users: %v", userIDs)
 
 		// 调用slack api获取多个用户的资料
-		profiles, err := slackClient.GetFilteredUsersProfile(userIDs)
+		profiles, err := slackClient.GetFilteredUsersProfile(ctx, userIDs)
 		if err != nil {
 			log.Printf("failed to get user profiles: %v", err)
-			return nil, fmt.Errorf("failed to get user profiles: %v", err)
+			return slackErrorResult(slackClient, request, "failed to get user profiles", err), nil
 		}
 		log.Printf("success to get user profiles")
 
 		// 序列化结果
 		profilesJSON, err := json.Marshal(profiles)
 		if err != nil {
-			return nil, fmt.Errorf("failed to serialize user profiles: %v", err)
+			return slackErrorResult(slackClient, request, "failed to serialize user profiles", err), nil
 		}
 
 		return mcp.NewToolResultText(fmt.Sprintf("user profiles: \n%s", string(profilesJSON))), nil
 	})
 
-	// start standard input/output server
-	log.Printf("MCP server is ready, start to process requests...")
-	if err := server.ServeStdio(s); err != nil {
-		fmt.Printf("Server error: %v\n", err)
+	addTool(getChannelHistoryTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slackClient, err := clientFor(workspaces, request)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+
+		channelID, ok := request.Params.Arguments["channel_id"].(string)
+		if !ok || channelID == "" {
+			log.Printf("error: invalid channel_id: %v", request.Params.Arguments["channel_id"])
+			return argumentError("channel_id is required"), nil
+		}
+
+		channelID, err = slackClient.ResolveChannelID(ctx, channelID)
+		if err != nil {
+			log.Printf("failed to resolve channel: %v", err)
+			return slackErrorResult(slackClient, request, "failed to resolve channel", err), nil
+		}
+
+		params := &slack.GetChannelHistoryParameters{
+			ChannelID: channelID,
+			Limit:     100,
+		}
+		if l, ok := request.Params.Arguments["limit"].(float64); ok {
+			params.Limit = int(l)
+		}
+		if params.Limit <= 0 || params.Limit > 999 {
+			return argumentError("limit must be between 1 and 999"), nil
+		}
+		params.Oldest, _ = request.Params.Arguments["oldest"].(string)
+		params.Latest, _ = request.Params.Arguments["latest"].(string)
+		params.Inclusive, _ = request.Params.Arguments["inclusive"].(bool)
+		params.Cursor, _ = request.Params.Arguments["cursor"].(string)
+		outputFormat, err := outputFormatArgument(request.Params.Arguments)
+		if err != nil {
+			return argumentError(err.Error()), nil
+		}
+
+		log.Printf("start to get channel history: channel=%s oldest=%q latest=%q limit=%d cursor=%q",
+			channelID, params.Oldest, params.Latest, params.Limit, params.Cursor)
+
+		// call slack api to get channel history
+		result, err := slackClient.GetChannelHistory(ctx, params)
+		if err != nil {
+			log.Printf("failed to get channel history: %v", err)
+			return slackErrorResult(slackClient, request, "failed to get channel history", err), nil
+		}
+		log.Printf("success to get channel history: %d messages, has_more=%t", len(result.Messages), result.HasMore)
+
+		var output interface{} = result
+		switch outputFormat {
+		case "compact_json":
+			output = &slack.RenderedChannelHistory{
+				Messages:   slackClient.RenderMessages(ctx, result.Messages),
+				HasMore:    result.HasMore,
+				NextCursor: result.NextCursor,
+			}
+		case "markdown_transcript":
+			// conversations.history returns the newest message first; transcripts read oldest first
+			messages := slackClient.RenderMessages(ctx, result.Messages)
+			slices.Reverse(messages)
+			transcript := slack.FormatTranscript(messages)
+			if result.HasMore {
+				transcript += fmt.Sprintf("\n_older messages available with cursor %s_\n", result.NextCursor)
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("channel history of %s:\n\n%s", channelID, transcript)), nil
+		}
+
+		historyJSON, err := json.Marshal(output)
+		if err != nil {
+			return slackErrorResult(slackClient, request, "failed to serialize channel history", err), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("channel history: \n%s", string(historyJSON))), nil
+	})
+
+	addTool(replyToThreadTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slackClient, err := clientFor(workspaces, request)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+
+		channelID, threadTS, err := messageRefFromArguments(request.Params.Arguments, "thread_ts", "thread_url", true)
+		if err != nil {
+			log.Printf("error: invalid thread: %v", err)
+			return argumentError(err.Error()), nil
+		}
+
+		text, ok := request.Params.Arguments["text"].(string)
+		if !ok || text == "" {
+			log.Printf("error: invalid text: %v", request.Params.Arguments["text"])
+			return argumentError("text is required"), nil
+		}
+
+		params := &slack.PostMessageParameters{
+			ChannelID: channelID,
+			Text:      text,
+			ThreadTS:  threadTS,
+		}
+		params.ReplyBroadcast, _ = request.Params.Arguments["reply_broadcast"].(bool)
+
+		format, _ := request.Params.Arguments["format"].(string)
+		if err := applyMessageFormat(params, format); err != nil {
+			return argumentError(err.Error()), nil
+		}
+
+		log.Printf("replying to thread: channel=%s thread_ts=%s broadcast=%t format=%q", channelID, threadTS, params.ReplyBroadcast, format)
+
+		// call slack api to post the reply
+		message, err := slackClient.PostReply(ctx, params)
+		if err != nil {
+			log.Printf("failed to post reply: %v", err)
+			return slackErrorResult(slackClient, request, "failed to post reply", err), nil
+		}
+		log.Printf("success to post reply: ts=%s", message.Timestamp)
+
+		messageJSON, err := json.Marshal(message)
+		if err != nil {
+			return slackErrorResult(slackClient, request, "failed to serialize message", err), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("reply posted: \n%s", string(messageJSON))), nil
+	})
+
+	addTool(scheduleMessageTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ws, err := workspaceFor(workspaces, request)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+		slackClient := ws.Client
+
+		channelID, ok := request.Params.Arguments["channel_id"].(string)
+		if !ok || channelID == "" {
+			log.Printf("error: invalid channel_id: %v", request.Params.Arguments["channel_id"])
+			return argumentError("channel_id is required"), nil
+		}
+		postAtValue, ok := request.Params.Arguments["post_at"].(string)
+		if !ok || strings.TrimSpace(postAtValue) == "" {
+			log.Printf("error: invalid post_at: %v", request.Params.Arguments["post_at"])
+			return argumentError("post_at is required"), nil
+		}
+		var loc *time.Location
+		if tz, _ := request.Params.Arguments["timezone"].(string); tz != "" {
+			if loc, err = time.LoadLocation(tz); err != nil {
+				return argumentError(fmt.Sprintf("unknown timezone %q: use an IANA time zone such as Europe/Berlin", tz)), nil
+			}
+		}
+
+		params := &slack.PostMessageParameters{}
+		params.Text, _ = request.Params.Arguments["text"].(string)
+		if data, ok, err := jsonArgument(request.Params.Arguments, "blocks"); err != nil {
+			return argumentError(err.Error()), nil
+		} else if ok {
+			if params.Blocks, err = slack.ParseBlocks(data); err != nil {
+				log.Printf("error: invalid blocks: %v", err)
+				return argumentError(fmt.Sprintf("invalid blocks: %v", err)), nil
+			}
+		}
+		if params.Text == "" && len(params.Blocks) == 0 {
+			log.Printf("error: invalid text: %v", request.Params.Arguments["text"])
+			return argumentError("text is required unless blocks are given"), nil
+		}
+		params.ThreadTS, _ = request.Params.Arguments["thread_ts"].(string)
+		params.ReplyBroadcast, _ = request.Params.Arguments["reply_broadcast"].(bool)
+		format, _ := request.Params.Arguments["format"].(string)
+		if err := applyMessageFormat(params, format); err != nil {
+			return argumentError(err.Error()), nil
+		}
+
+		channelID, err = slackClient.ResolveChannelID(ctx, channelID)
+		if err != nil {
+			log.Printf("failed to resolve channel: %v", err)
+			return slackErrorResult(slackClient, request, "failed to resolve channel", err), nil
+		}
+		params.ChannelID = channelID
+		if loc == nil {
+			if loc, err = scheduleLocation(ctx, slackClient, channelID, ws.Identity.UserID); err != nil {
+				log.Printf("failed to look up the time zone: %v", err)
+				return slackErrorResult(slackClient, request, "failed to look up the time zone; pass timezone to skip the lookup", err), nil
+			}
+		}
+
+		postAt, err := slack.ParseScheduleTime(postAtValue, time.Now(), loc)
+		if err != nil {
+			return argumentError(err.Error()), nil
+		}
+		if err := slack.CheckScheduleTime(postAt, time.Now()); err != nil {
+			return argumentError(fmt.Sprintf("invalid post_at: %v", err)), nil
+		}
+
+		log.Printf("scheduling message: channel=%s post_at=%s", channelID, postAt.Format(time.RFC3339))
+
+		// call slack api to schedule the message
+		scheduled, err := slackClient.ScheduleMessage(ctx, params, postAt)
+		if err != nil {
+			log.Printf("failed to schedule message: %v", err)
+			return slackErrorResult(slackClient, request, "failed to schedule message", err), nil
+		}
+		log.Printf("success to schedule message: %s", scheduled.ID)
+
+		result := struct {
+			*slack.ScheduledMessage
+			// TimeZone is the time zone post_at was read in
+			TimeZone string `json:"timezone"`
+		}{scheduled, loc.String()}
+		resultJSON, err := json.Marshal(result)
+		if err != nil {
+			return slackErrorResult(slackClient, request, "failed to serialize scheduled message", err), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("message scheduled: \n%s", string(resultJSON))), nil
+	})
+
+	addTool(listScheduledMessagesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slackClient, err := clientFor(workspaces, request)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+
+		params := &slack.ListScheduledMessagesParameters{Limit: 100}
+		if l, ok := request.Params.Arguments["limit"].(float64); ok {
+			params.Limit = int(l)
+		}
+		if params.Limit <= 0 || params.Limit > 100 {
+			return argumentError("limit must be between 1 and 100"), nil
+		}
+		params.Cursor, _ = request.Params.Arguments["cursor"].(string)
+		if channel, _ := request.Params.Arguments["channel_id"].(string); channel != "" {
+			if params.ChannelID, err = slackClient.ResolveChannelID(ctx, channel); err != nil {
+				log.Printf("failed to resolve channel: %v", err)
+				return slackErrorResult(slackClient, request, "failed to resolve channel", err), nil
+			}
+		}
+
+		log.Printf("listing scheduled messages: channel=%q limit=%d", params.ChannelID, params.Limit)
+
+		// call slack api to list the scheduled messages
+		result, err := slackClient.ListScheduledMessages(ctx, params)
+		if err != nil {
+			log.Printf("failed to list scheduled messages: %v", err)
+			return slackErrorResult(slackClient, request, "failed to list scheduled messages", err), nil
+		}
+		log.Printf("success to list scheduled messages: %d messages", len(result.ScheduledMessages))
+
+		resultJSON, err := json.Marshal(result)
+		if err != nil {
+			return slackErrorResult(slackClient, request, "failed to serialize scheduled messages", err), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("scheduled messages: \n%s", string(resultJSON))), nil
+	})
+
+	addTool(deleteScheduledMessageTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slackClient, err := clientFor(workspaces, request)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+
+		channelID, ok := request.Params.Arguments["channel_id"].(string)
+		if !ok || channelID == "" {
+			log.Printf("error: invalid channel_id: %v", request.Params.Arguments["channel_id"])
+			return argumentError("channel_id is required"), nil
+		}
+		scheduledMessageID, ok := request.Params.Arguments["scheduled_message_id"].(string)
+		if !ok || scheduledMessageID == "" {
+			log.Printf("error: invalid scheduled_message_id: %v", request.Params.Arguments["scheduled_message_id"])
+			return argumentError("scheduled_message_id is required"), nil
+		}
+		channelID, err = slackClient.ResolveChannelID(ctx, channelID)
+		if err != nil {
+			log.Printf("failed to resolve channel: %v", err)
+			return slackErrorResult(slackClient, request, "failed to resolve channel", err), nil
+		}
+
+		log.Printf("deleting scheduled message: channel=%s id=%s", channelID, scheduledMessageID)
+
+		// call slack api to delete the scheduled message
+		if err := slackClient.DeleteScheduledMessage(ctx, channelID, scheduledMessageID); err != nil {
+			log.Printf("failed to delete scheduled message: %v", err)
+			return slackErrorResult(slackClient, request, "failed to delete scheduled message", err), nil
+		}
+		log.Printf("success to delete scheduled message")
+
+		return mcp.NewToolResultText(fmt.Sprintf("scheduled message %s in channel %s deleted", scheduledMessageID, channelID)), nil
+	})
+
+	addTool(addReactionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slackClient, err := clientFor(workspaces, request)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+
+		channelID, timestamp, err := messageRefFromArguments(request.Params.Arguments, "timestamp", "message_url", false)
+		if err != nil {
+			log.Printf("error: invalid message: %v", err)
+			return argumentError(err.Error()), nil
+		}
+
+		reaction, ok := request.Params.Arguments["reaction"].(string)
+		if !ok || reaction == "" {
+			log.Printf("error: invalid reaction: %v", request.Params.Arguments["reaction"])
+			return argumentError("reaction is required"), nil
+		}
+
+		log.Printf("adding reaction %s to message: channel=%s ts=%s", reaction, channelID, timestamp)
+
+		// call slack api to add the reaction
+		if err := slackClient.AddReaction(ctx, channelID, timestamp, reaction); err != nil {
+			log.Printf("failed to add reaction: %v", err)
+			return slackErrorResult(slackClient, request, "failed to add reaction", err), nil
+		}
+		log.Printf("success to add reaction")
+
+		return mcp.NewToolResultText(fmt.Sprintf("reaction %s added to message %s in channel %s", reaction, timestamp, channelID)), nil
+	})
+
+	addTool(removeReactionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slackClient, err := clientFor(workspaces, request)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+
+		channelID, timestamp, err := messageRefFromArguments(request.Params.Arguments, "timestamp", "message_url", false)
+		if err != nil {
+			log.Printf("error: invalid message: %v", err)
+			return argumentError(err.Error()), nil
+		}
+
+		reaction, ok := request.Params.Arguments["reaction"].(string)
+		if !ok || reaction == "" {
+			log.Printf("error: invalid reaction: %v", request.Params.Arguments["reaction"])
+			return argumentError("reaction is required"), nil
+		}
+
+		log.Printf("removing reaction %s from message: channel=%s ts=%s", reaction, channelID, timestamp)
+
+		// call slack api to remove the reaction
+		if err := slackClient.RemoveReaction(ctx, channelID, timestamp, reaction); err != nil {
+			log.Printf("failed to remove reaction: %v", err)
+			return slackErrorResult(slackClient, request, "failed to remove reaction", err), nil
+		}
+		log.Printf("success to remove reaction")
+
+		return mcp.NewToolResultText(fmt.Sprintf("reaction %s removed from message %s in channel %s", reaction, timestamp, channelID)), nil
+	})
+
+	addTool(getReactionsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slackClient, err := clientFor(workspaces, request)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+
+		channelID, timestamp, err := messageRefFromArguments(request.Params.Arguments, "timestamp", "message_url", false)
+		if err != nil {
+			log.Printf("error: invalid message: %v", err)
+			return argumentError(err.Error()), nil
+		}
+
+		log.Printf("getting reactions of message: channel=%s ts=%s", channelID, timestamp)
+
+		// call slack api to get the reactions
+		reactions, err := slackClient.GetReactions(ctx, channelID, timestamp)
+		if err != nil {
+			log.Printf("failed to get reactions: %v", err)
+			return slackErrorResult(slackClient, request, "failed to get reactions", err), nil
+		}
+		log.Printf("success to get reactions: %d emoji", len(reactions))
+
+		reactionsJSON, err := json.Marshal(reactions)
+		if err != nil {
+			return slackErrorResult(slackClient, request, "failed to serialize reactions", err), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("reactions: \n%s", string(reactionsJSON))), nil
+	})
+
+	addTool(searchMessagesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slackClient, err := clientFor(workspaces, request)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+
+		query, ok := request.Params.Arguments["query"].(string)
+		if !ok || query == "" {
+			log.Printf("error: invalid query: %v", request.Params.Arguments["query"])
+			return argumentError("query is required"), nil
+		}
+
+		params := &slack.SearchMessagesParameters{
+			Query: query,
+			Count: 20,
+			Page:  1,
+		}
+		params.Sort, _ = request.Params.Arguments["sort"].(string)
+		if params.Sort != "" && params.Sort != "score" && params.Sort != "timestamp" {
+			return argumentError("sort must be one of: score, timestamp"), nil
+		}
+		params.SortDirection, _ = request.Params.Arguments["sort_dir"].(string)
+		if params.SortDirection != "" && params.SortDirection != "asc" && params.SortDirection != "desc" {
+			return argumentError("sort_dir must be one of: asc, desc"), nil
+		}
+		if c, ok := request.Params.Arguments["count"].(float64); ok {
+			params.Count = int(c)
+		}
+		if params.Count <= 0 || params.Count > 100 {
+			return argumentError("count must be between 1 and 100"), nil
+		}
+		if p, ok := request.Params.Arguments["page"].(float64); ok {
+			params.Page = int(p)
+		}
+		if params.Page <= 0 {
+			return argumentError("page must be greater than 0"), nil
+		}
+		outputFormat, err := outputFormatArgument(request.Params.Arguments)
+		if err != nil {
+			return argumentError(err.Error()), nil
+		}
+
+		log.Printf("searching messages: query=%q sort=%s sort_dir=%s count=%d page=%d",
+			query, params.Sort, params.SortDirection, params.Count, params.Page)
+
+		// call slack api to search messages
+		result, err := slackClient.SearchMessages(ctx, params)
+		if err != nil {
+			log.Printf("failed to search messages: %v", err)
+			return slackErrorResult(slackClient, request, "failed to search messages", err), nil
+		}
+		log.Printf("success to search messages: %d of %d matches", len(result.Matches), result.Total)
+
+		var output interface{} = result
+		switch outputFormat {
+		case "compact_json":
+			output = &slack.RenderedSearchResults{
+				Query:     result.Query,
+				Total:     result.Total,
+				Page:      result.Page,
+				PageCount: result.PageCount,
+				Matches:   slackClient.RenderSearchMatches(ctx, result.Matches),
+			}
+		case "markdown_transcript":
+			transcript := slack.FormatTranscript(slackClient.RenderSearchMatches(ctx, result.Matches))
+			return mcp.NewToolResultText(fmt.Sprintf("search results for %q (page %d of %d, %d matches):\n\n%s",
+				result.Query, result.Page, result.PageCount, result.Total, transcript)), nil
+		}
+
+		resultJSON, err := json.Marshal(output)
+		if err != nil {
+			return slackErrorResult(slackClient, request, "failed to serialize search results", err), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("search results: \n%s", string(resultJSON))), nil
+	})
+
+	addTool(listUsersTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slackClient, err := clientFor(workspaces, request)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+
+		params := &slack.GetUsersParameters{
+			Limit:         100,
+			IncludeGuests: true,
+		}
+		if l, ok := request.Params.Arguments["limit"].(float64); ok {
+			params.Limit = int(l)
+		}
+		if params.Limit <= 0 || params.Limit > 200 {
+			return argumentError("limit must be between 1 and 200"), nil
+		}
+		params.Cursor, _ = request.Params.Arguments["cursor"].(string)
+		params.IncludeDeleted, _ = request.Params.Arguments["include_deleted"].(bool)
+		params.IncludeBots, _ = request.Params.Arguments["include_bots"].(bool)
+		if g, ok := request.Params.Arguments["include_guests"].(bool); ok {
+			params.IncludeGuests = g
+		}
+		params.TimeZone, _ = request.Params.Arguments["tz"].(string)
+
+		log.Printf("start to list users: limit=%d cursor=%q deleted=%t bots=%t guests=%t tz=%q",
+			params.Limit, params.Cursor, params.IncludeDeleted, params.IncludeBots, params.IncludeGuests, params.TimeZone)
+
+		// call slack api to list users
+		result, err := slackClient.GetUsers(ctx, params)
+		if err != nil {
+			log.Printf("failed to list users: %v", err)
+			return slackErrorResult(slackClient, request, "failed to list users", err), nil
+		}
+		log.Printf("success to list users: %d users", len(result.Members))
+
+		page := &slack.UserDirectoryPage{
+			Users:      make([]*slack.UserProfileInfo, 0, len(result.Members)),
+			NextCursor: result.ResponseMetadata.NextCursor,
+		}
+		for i := range result.Members {
+			page.Users = append(page.Users, slack.NewUserProfileInfo(&result.Members[i]))
+		}
+
+		pageJSON, err := json.Marshal(page)
+		if err != nil {
+			return slackErrorResult(slackClient, request, "failed to serialize user list", err), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("user list: \n%s", string(pageJSON))), nil
+	})
+
+	addTool(findUserTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slackClient, err := clientFor(workspaces, request)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+
+		query, ok := request.Params.Arguments["query"].(string)
+		if !ok || query == "" {
+			log.Printf("error: invalid query: %v", request.Params.Arguments["query"])
+			return argumentError("query is required"), nil
+		}
+
+		limit := 5
+		if l, ok := request.Params.Arguments["limit"].(float64); ok {
+			limit = int(l)
+		}
+		if limit <= 0 {
+			return argumentError("limit must be greater than 0"), nil
+		}
+
+		log.Printf("finding user: query=%q limit=%d", query, limit)
+
+		// call slack api to find the user
+		candidates, err := slackClient.FindUsers(ctx, query, limit)
+		if err != nil {
+			log.Printf("failed to find user: %v", err)
+			return slackErrorResult(slackClient, request, "failed to find user", err), nil
+		}
+		log.Printf("success to find user: %d candidates", len(candidates))
+
+		candidatesJSON, err := json.Marshal(candidates)
+		if err != nil {
+			return slackErrorResult(slackClient, request, "failed to serialize user candidates", err), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("user candidates: \n%s", string(candidatesJSON))), nil
+	})
+
+	addTool(findChannelTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slackClient, err := clientFor(workspaces, request)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+
+		query, ok := request.Params.Arguments["query"].(string)
+		if !ok || query == "" {
+			log.Printf("error: invalid query: %v", request.Params.Arguments["query"])
+			return argumentError("query is required"), nil
+		}
+
+		var types []string
+		if typesInterface, ok := request.Params.Arguments["types"].([]interface{}); ok {
+			for i, v := range typesInterface {
+				t, ok := v.(string)
+				if !ok || !slices.Contains(slack.AllConversationTypes, t) {
+					return argumentError(fmt.Sprintf("invalid conversation type at position %d: %v", i, v)), nil
+				}
+				types = append(types, t)
+			}
+		}
+
+		log.Printf("finding channel: query=%q types=%v", query, types)
+
+		// call slack api to find the channel
+		matches, err := slackClient.FindChannels(ctx, query, types)
+		if err != nil {
+			log.Printf("failed to find channel: %v", err)
+			return slackErrorResult(slackClient, request, "failed to find channel", err), nil
+		}
+		log.Printf("success to find channel: %d matches", len(matches))
+
+		matchesJSON, err := json.Marshal(matches)
+		if err != nil {
+			return slackErrorResult(slackClient, request, "failed to serialize channel matches", err), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("channel matches: \n%s", string(matchesJSON))), nil
+	})
+
+	addTool(listWorkspacesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		list := workspaces.List()
+		log.Printf("listing %d workspaces", len(list))
+
+		workspacesJSON, err := json.Marshal(list)
+		if err != nil {
+			return slackErrorResult(nil, request, "failed to serialize workspaces", err), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("workspaces: \n%s", string(workspacesJSON))), nil
+	})
+
+	addTool(whoamiTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, _ := request.Params.Arguments["workspace"].(string)
+		ws, err := workspaces.Get(name)
+		if err != nil {
+			return slackErrorResult(nil, request, "", err), nil
+		}
+
+		missing := make(map[string][]string)
+		for tool, requirements := range toolScopes {
+			for _, requirement := range ws.Missing(requirements) {
+				missing[tool] = append(missing[tool], requirement.String())
+			}
+		}
+		whoami := struct {
+			Workspace string `json:"workspace"`
+			*slack.Identity
+			// MissingScopes maps each unavailable tool to the scopes it lacks
+			MissingScopes map[string][]string `json:"missing_scopes"`
+			// RateLimits is the state of the rate-limit queue of every method called so far
+			RateLimits []slack.RateLimitStats `json:"rate_limits"`
+		}{ws.Alias, ws.Identity, missing, ws.Client.RateLimitStats()}
+		log.Printf("whoami: workspace %s, %d tools missing scopes", ws.Alias, len(missing))
+
+		whoamiJSON, err := json.Marshal(whoami)
+		if err != nil {
+			return slackErrorResult(nil, request, "failed to serialize identity", err), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("identity: \n%s", string(whoamiJSON))), nil
+	})
+
+	if eventBuffer == nil {
+		toolNames[waitForEventsTool.Name] = true
+		log.Printf("skip tool %s: set SLACK_APP_TOKEN or SLACK_SIGNING_SECRET to receive events", waitForEventsTool.Name)
+	} else {
+		addTool(waitForEventsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			ws, err := workspaceFor(workspaces, request)
+			if err != nil {
+				return slackErrorResult(nil, request, "", err), nil
+			}
+			slackClient := ws.Client
+
+			filter := events.Filter{Limit: 50}
+			// events carry the workspace's team ID, which org-wide tokens (E...) do not have
+			if strings.HasPrefix(ws.TeamID, "T") {
+				filter.TeamID = ws.TeamID
+			}
+			if limit, ok := request.Params.Arguments["limit"].(float64); ok {
+				if limit <= 0 || limit > 200 {
+					return argumentError("limit must be between 1 and 200"), nil
+				}
+				filter.Limit = int(limit)
+			}
+			if after, ok := request.Params.Arguments["after"].(float64); ok {
+				if after < 0 {
+					return argumentError("after must not be negative"), nil
+				}
+				filter.After = int64(after)
+			}
+			timeout := 30 * time.Second
+			if seconds, ok := request.Params.Arguments["timeout_seconds"].(float64); ok {
+				if seconds < 0 || seconds > maxEventWait.Seconds() {
+					return argumentError(fmt.Sprintf("timeout_seconds must be between 0 and %d", int(maxEventWait.Seconds()))), nil
+				}
+				timeout = time.Duration(seconds * float64(time.Second))
+			}
+			if typesInterface, ok := request.Params.Arguments["types"].([]interface{}); ok {
+				for i, v := range typesInterface {
+					t, ok := v.(string)
+					if !ok || !slices.Contains(events.Types, t) {
+						return argumentError(fmt.Sprintf("invalid event type at position %d: %v", i, v)), nil
+					}
+					filter.Types = append(filter.Types, t)
+				}
+			}
+			filter.ThreadTS, _ = request.Params.Arguments["thread_ts"].(string)
+			if channel, _ := request.Params.Arguments["channel"].(string); channel != "" {
+				channelID, err := slackClient.ResolveChannelID(ctx, channel)
+				if err != nil {
+					return slackErrorResult(slackClient, request, "failed to resolve channel", err), nil
+				}
+				filter.Channels = []string{channelID}
+			}
+			if user, _ := request.Params.Arguments["user"].(string); user != "" {
+				userID, err := slackClient.ResolveUserID(ctx, user)
+				if err != nil {
+					return slackErrorResult(slackClient, request, "failed to resolve user", err), nil
+				}
+				filter.User = userID
+			}
+
+			log.Printf("waiting for events: channels=%v types=%v after=%d timeout=%s", filter.Channels, filter.Types, filter.After, timeout)
+			waitCtx, cancel := context.WithTimeout(ctx, timeout)
+			received, cursor := eventBuffer.Wait(waitCtx, filter)
+			cancel()
+			log.Printf("success to wait for events: %d events, cursor=%d", len(received), cursor)
+
+			result := struct {
+				Events []events.Event `json:"events"`
+				// Cursor is the after of the next call
+				Cursor int64 `json:"cursor"`
+			}{make([]events.Event, 0, len(received)), cursor}
+			for _, e := range received {
+				e.Text = slackClient.RenderText(ctx, e.Text)
+				result.Events = append(result.Events, e)
+			}
+
+			resultJSON, err := json.Marshal(result)
+			if err != nil {
+				return slackErrorResult(slackClient, request, "failed to serialize events", err), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("events: \n%s", string(resultJSON))), nil
+		})
+	}
+
+	addResources(s, workspaces, toolTimeout)
+	addPrompts(s, workspaces, toolTimeout)
+
+	for name := range timeouts {
+		if !toolNames[name] {
+			return nil, fmt.Errorf("unknown tool %s", name)
+		}
+	}
+	return s, nil
+}
+
+// workspaceConfig returns the workspaces file, or a single workspace built from SLACK_TOKEN and SLACK_TEAM_ID without one
+func workspaceConfig(path string) (*workspace.Config, error) {
+	if path != "" {
+		return workspace.LoadConfig(path)
+	}
+
+	token := os.Getenv("SLACK_TOKEN")
+	if token == "" {
+		token = os.Getenv("SLACK_BOT_TOKEN")
+	}
+	teamID := os.Getenv("SLACK_TEAM_ID")
+	if token == "" || teamID == "" {
+		return nil, fmt.Errorf("please set SLACK_TOKEN (or SLACK_BOT_TOKEN) and SLACK_TEAM_ID environment variables, or a workspaces file with --workspaces")
+	}
+	return &workspace.Config{
+		Workspaces: []workspace.WorkspaceConfig{{
+			Alias:  workspace.DefaultAlias,
+			TeamID: teamID,
+			Token:  token,
+			APIURL: os.Getenv("SLACK_API_URL"),
+		}},
+	}, nil
+}
+
+// workspaceOption declares the workspace argument every tool accepts
+func workspaceOption() mcp.ToolOption {
+	return mcp.WithString("workspace",
+		mcp.Description("alias or team ID of the workspace to act in (see slack_list_workspaces); the default workspace when omitted"),
+	)
+}
+
+// clientFor returns the Slack client of the workspace a tool call selects,
+// failing when that workspace's token lacks the scopes the tool needs
+func clientFor(workspaces *workspace.Registry, request mcp.CallToolRequest) (*slack.Client, error) {
+	ws, err := workspaceFor(workspaces, request)
+	if err != nil {
+		return nil, err
+	}
+	return ws.Client, nil
+}
+
+// workspaceFor returns the workspace a tool call selects, like clientFor, for tools that
+// also need its identity or team
+func workspaceFor(workspaces *workspace.Registry, request mcp.CallToolRequest) (*workspace.Workspace, error) {
+	name, _ := request.Params.Arguments["workspace"].(string)
+	ws, err := workspaces.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if missing := ws.Missing(toolScopes[request.Params.Name]); len(missing) > 0 {
+		scopeErr := &slack.MissingScopeError{Provided: ws.Identity.Scopes}
+		for _, requirement := range missing {
+			scopeErr.Needed = append(scopeErr.Needed, requirement...)
+		}
+		return nil, fmt.Errorf("%s is not available in workspace %s (see slack_whoami): %w", request.Params.Name, ws.Alias, scopeErr)
+	}
+	return ws, nil
+}
+
+// requirementsString lists scope requirements, e.g. "users:read, chat:write"
+func requirementsString(requirements []workspace.Requirement) string {
+	names := make([]string, 0, len(requirements))
+	for _, requirement := range requirements {
+		names = append(names, requirement.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// defaultAPIURL returns the Web API base URL configured for the default workspace
+func defaultAPIURL(config *workspace.Config, workspaces *workspace.Registry) string {
+	ws, err := workspaces.Get("")
+	if err != nil {
+		return ""
+	}
+	for _, wc := range config.Workspaces {
+		if wc.Alias == ws.Alias {
+			return wc.APIURL
+		}
+	}
+	return ""
+}
+
+// intEnv returns the integer in an environment variable, or fallback when it is unset
+func intEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return n
+}
+
+// durationEnv returns the duration in an environment variable, or fallback when it is unset
+func durationEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return d
+}
+
+// parseToolTimeouts adds per-tool timeouts given as tool=duration pairs separated by commas
+func parseToolTimeouts(spec string, timeouts map[string]time.Duration) error {
+	for _, pair := range strings.Split(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("%q is not tool=duration", pair)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d <= 0 {
+			return fmt.Errorf("%q: timeout must be a positive duration such as 30s or 2m", pair)
+		}
+		timeouts[strings.TrimSpace(name)] = d
+	}
+	return nil
+}
+
+// envOrDefault returns the value of an environment variable, or fallback when it is unset or empty
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// toolResultError builds a tool result reporting a failure the model can read and act on
+func toolResultError(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{mcp.NewTextContent(text)},
+		IsError: true,
+	}
+}
+
+// toolErrorResult reports a failure as a readable message followed by a JSON object
+// with its machine-readable code, e.g. {"error":{"code":"not_in_channel",...}}
+func toolErrorResult(details *slack.ErrorDetails) *mcp.CallToolResult {
+	detailsJSON, err := json.Marshal(map[string]*slack.ErrorDetails{"error": details})
+	if err != nil {
+		return toolResultError(details.Message)
+	}
+	return toolResultError(fmt.Sprintf("%s\n%s", details.Message, string(detailsJSON)))
+}
+
+// argumentError reports an invalid tool argument
+func argumentError(message string) *mcp.CallToolResult {
+	return toolErrorResult(&slack.ErrorDetails{Code: "invalid_argument", Message: message})
+}
+
+// slackErrorResult reports a failed call, classified by slack.DescribeError, prefixed with the
+// action that failed. missing_scope errors are completed with the scopes the tool needs and the
+// scopes the workspace's token has when Slack did not report them.
+func slackErrorResult(slackClient *slack.Client, request mcp.CallToolRequest, action string, err error) *mcp.CallToolResult {
+	details := slack.DescribeError(err)
+	if errors.Is(err, workspace.ErrUnknownWorkspace) {
+		details.Code = "unknown_workspace"
+	}
+	if details.Code == "missing_scope" {
+		if len(details.NeededScopes) == 0 {
+			for _, requirement := range toolScopes[request.Params.Name] {
+				details.NeededScopes = append(details.NeededScopes, requirement...)
+			}
+		}
+		if len(details.ProvidedScopes) == 0 && slackClient != nil {
+			details.ProvidedScopes = slackClient.Scopes()
+		}
+	}
+	if action != "" {
+		details.Message = fmt.Sprintf("%s: %s", action, details.Message)
+	}
+	return toolErrorResult(details)
+}
+
+// messageFormatOption declares the format argument shared by the tools that post messages
+func messageFormatOption() mcp.ToolOption {
+	return mcp.WithString("format",
+		mcp.Description("how text is written: mrkdwn (Slack's own syntax, sent as is), markdown (CommonMark, converted to mrkdwn) "+
+			"or markdown_blocks (CommonMark, converted to header and section blocks with a mrkdwn fallback)"),
+		mcp.Enum("mrkdwn", "markdown", "markdown_blocks"),
+		mcp.DefaultString("mrkdwn"),
+	)
+}
+
+// outputFormatOption declares the output_format argument shared by the tools that read messages
+func outputFormatOption() mcp.ToolOption {
+	return mcp.WithString("output_format",
+		mcp.Description("how messages are returned: compact_json (mentions resolved to names, blocks and attachments flattened into text), "+
+			"markdown_transcript (the same, as a readable transcript) or raw (Slack's message objects as returned by the API)"),
+		mcp.Enum("compact_json", "markdown_transcript", "raw"),
+		mcp.DefaultString("compact_json"),
+	)
+}
+
+// outputFormatArgument returns the output_format argument of a tool that reads messages
+func outputFormatArgument(arguments map[string]interface{}) (string, error) {
+	format, _ := arguments["output_format"].(string)
+	switch format {
+	case "":
+		return "compact_json", nil
+	case "compact_json", "markdown_transcript", "raw":
+		return format, nil
+	default:
+		return "", fmt.Errorf("output_format must be one of: compact_json, markdown_transcript, raw")
+	}
+}
+
+// applyMessageFormat converts the text of a message written in the given format into what Slack renders
+func applyMessageFormat(params *slack.PostMessageParameters, format string) error {
+	switch format {
+	case "", "mrkdwn":
+		return nil
+	case "markdown":
+		params.Text = mrkdwn.Convert(params.Text)
+		return nil
+	case "markdown_blocks":
+		if len(params.Blocks) > 0 {
+			return fmt.Errorf("format markdown_blocks builds the blocks from text and cannot be combined with blocks")
+		}
+		if blocks, ok := mrkdwn.ConvertBlocks(params.Text); ok {
+			params.Blocks = blocks
+		} else {
+			log.Printf("markdown needs more than %d blocks, sending it as mrkdwn text", mrkdwn.MaxBlocks)
+		}
+		params.Text = mrkdwn.Convert(params.Text)
+		return nil
+	default:
+		return fmt.Errorf("format must be one of: mrkdwn, markdown, markdown_blocks")
+	}
+}
+
+// scheduleLocation returns the time zone a scheduled message's post_at is read in when the
+// call names none: the profile time zone of the direct message's recipient, otherwise of the
+// user the token acts as, otherwise UTC
+func scheduleLocation(ctx context.Context, slackClient *slack.Client, channelID, selfUserID string) (*time.Location, error) {
+	userID := selfUserID
+	if strings.HasPrefix(channelID, "D") {
+		// the recipient is only a default; without im:read fall back to the token's user
+		if matches, err := slackClient.FindChannels(ctx, channelID, []string{"im"}); err != nil {
+			log.Printf("failed to look up the recipient of %s: %v", channelID, err)
+		} else if len(matches) == 1 && matches[0].User != "" {
+			userID = matches[0].User
+		}
+	}
+	if userID == "" {
+		return time.UTC, nil
+	}
+	loc, err := slackClient.UserLocation(ctx, userID)
+	if err != nil || loc == nil {
+		return time.UTC, err
+	}
+	return loc, nil
+}
+
+// jsonArgument returns the JSON encoding of an array or object argument. Clients that
+// cannot send structured arguments may pass the JSON as a string instead.
+func jsonArgument(arguments map[string]interface{}, key string) ([]byte, bool, error) {
+	switch v := arguments[key].(type) {
+	case nil:
+		return nil, false, nil
+	case string:
+		if v == "" {
+			return nil, false, nil
+		}
+		return []byte(v), true, nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid %s: %v", key, err)
+		}
+		return data, true, nil
+	}
+}
+
+// boolArgument returns a pointer to a boolean argument, or nil when it was not given
+func boolArgument(arguments map[string]interface{}, key string) *bool {
+	if v, ok := arguments[key].(bool); ok {
+		return &v
+	}
+	return nil
+}
+
+// messageRefFromArguments resolves the message a tool call targets, given either
+// channel_id + the timestamp argument named tsKey, or a Slack message URL under urlKey.
+// When thread is set, a reply URL resolves to the timestamp of the thread's parent message.
+func messageRefFromArguments(arguments map[string]interface{}, tsKey, urlKey string, thread bool) (channelID, timestamp string, err error) {
+	if messageURL, ok := arguments[urlKey].(string); ok && messageURL != "" {
+		link, err := slack.ParsePermalink(messageURL)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid %s: %v", urlKey, err)
+		}
+		if thread {
+			return link.ChannelID, link.ThreadTS, nil
+		}
+		return link.ChannelID, link.MessageTS, nil
+	}
+
+	channelID, _ = arguments["channel_id"].(string)
+	timestamp, _ = arguments[tsKey].(string)
+	if channelID == "" || timestamp == "" {
+		return "", "", fmt.Errorf("either %s or both channel_id and %s are required", urlKey, tsKey)
 	}
+	return channelID, timestamp, nil
 }
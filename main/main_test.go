@@ -0,0 +1,703 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	slackapi "github.com/slack-go/slack"
+
+	"github.com/shawnzhang/slack-go/pkg/events"
+	"github.com/shawnzhang/slack-go/pkg/fakeslack"
+	"github.com/shawnzhang/slack-go/pkg/slack"
+	"github.com/shawnzhang/slack-go/pkg/workspace"
+)
+
+// IDs of the fixture workspace seeded by newTestEnv
+const (
+	aliceID   = "U0ALICE001"
+	bobID     = "U0BOB00001"
+	carolID   = "U0CAROL001"
+	generalID = "C0GENERAL1"
+	randomID  = "C0RANDOM01"
+	secretID  = "G0SECRET01"
+	archiveID = "C0ARCHIVE1"
+	aliceIMID = "D0ALICE001"
+)
+
+// testPollInterval is how often subscribed resources are polled in tests
+const testPollInterval = 100 * time.Millisecond
+
+// testEventBufferSize is how many events per channel the test buffer keeps
+const testEventBufferSize = 10
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testEnv is an MCP server acting in a fake Slack workspace through two workspaces:
+// "bot" (the default) with the bot token and "user" with the user token
+type testEnv struct {
+	fake          *fakeslack.Server
+	workspaces    *workspace.Registry
+	server        *server.MCPServer
+	subscriptions *resourceSubscriptions
+	// events is the buffer slack_wait_for_events reads; tests add events to it directly or
+	// through a Socket Mode listener connected to fake
+	events *events.Buffer
+	// threadTS is the parent of the thread in #general with two replies
+	threadTS string
+	// helloTS is the first message in #general, which mentions @bob and #random
+	helloTS string
+}
+
+// newTestEnv seeds a fake workspace and starts an MCP server on it with the given tool timeout
+func newTestEnv(t *testing.T, toolTimeout time.Duration) *testEnv {
+	t.Helper()
+	fake := fakeslack.NewServer()
+	t.Cleanup(fake.Close)
+
+	fake.AddUser(slackapi.User{ID: aliceID, Name: "alice", RealName: "Alice Liddell", TZ: "Asia/Singapore",
+		Profile: slackapi.UserProfile{RealName: "Alice Liddell", DisplayName: "alice", Title: "Engineer"}})
+	fake.AddUser(slackapi.User{ID: bobID, Name: "bob", RealName: "Bob Builder", TZ: "Europe/London",
+		Profile: slackapi.UserProfile{RealName: "Bob Builder", DisplayName: "bob"}})
+	fake.AddUser(slackapi.User{ID: carolID, Name: "carol", RealName: "Carol Guest", IsRestricted: true,
+		Profile: slackapi.UserProfile{RealName: "Carol Guest", DisplayName: "carol"}})
+
+	channel := func(id, name string) slackapi.Channel {
+		var c slackapi.Channel
+		c.ID, c.Name = id, name
+		return c
+	}
+	general := channel(generalID, "general")
+	general.Topic.Value = "Company-wide announcements"
+	fake.AddChannel(general, fakeslack.BotUserID, fakeslack.UserID, aliceID, bobID)
+	fake.AddChannel(channel(randomID, "random"), fakeslack.UserID, aliceID)
+	secret := channel(secretID, "secret")
+	secret.IsPrivate = true
+	fake.AddChannel(secret, fakeslack.BotUserID, fakeslack.UserID)
+	archive := channel(archiveID, "general-archive")
+	archive.IsArchived = true
+	fake.AddChannel(archive, fakeslack.BotUserID)
+	im := channel(aliceIMID, "")
+	im.IsIM, im.User = true, aliceID
+	fake.AddChannel(im, fakeslack.BotUserID, aliceID)
+
+	env := &testEnv{fake: fake, events: events.NewBuffer(testEventBufferSize)}
+	env.helloTS = fake.AddMessage(generalID, message(aliceID, "Hello <@"+bobID+">, see <#"+randomID+"> &amp; the docs", ""))
+	env.threadTS = fake.AddMessage(generalID, message(bobID, "the deploy is done", ""))
+	fake.AddMessage(generalID, message(aliceID, "great, thanks", env.threadTS))
+	fake.AddMessage(generalID, message(fakeslack.UserID, "ship it", env.threadTS))
+	fake.AddMessage(secretID, message(fakeslack.UserID, "the deploy key rotates on Friday", ""))
+
+	workspaces, err := workspace.NewRegistry(context.Background(), &workspace.Config{
+		Default: "bot",
+		Workspaces: []workspace.WorkspaceConfig{
+			{Alias: "bot", TeamID: fakeslack.TeamID, Token: fakeslack.BotToken, APIURL: fake.URL},
+			{Alias: "user", TeamID: fakeslack.TeamID, Token: fakeslack.UserToken, APIURL: fake.URL},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to set up workspaces: %v", err)
+	}
+	env.workspaces = workspaces
+	env.server, err = newServer(workspaces, toolTimeout, nil, env.events)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	env.subscriptions = newResourceSubscriptions(workspaces, testPollInterval)
+	t.Cleanup(env.subscriptions.Close)
+	env.events.Listen(env.subscriptions.eventReceived)
+	return env
+}
+
+// message builds a fixture message, a reply when threadTS is set
+func message(userID, text, threadTS string) slackapi.Message {
+	var msg slackapi.Message
+	msg.User, msg.Text, msg.ThreadTimestamp = userID, text, threadTS
+	return msg
+}
+
+// permalink is the link the fake server gives a message in #general
+func permalink(ts string) string {
+	return "https://fake.slack.com/archives/" + generalID + "/p" + strings.ReplaceAll(ts, ".", "")
+}
+
+// call invokes a tool through the MCP protocol and returns its text and whether it failed
+func (env *testEnv) call(t *testing.T, name string, arguments map[string]any) (string, bool) {
+	t.Helper()
+	raw, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]any{"name": name, "arguments": arguments},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, ok := env.server.HandleMessage(context.Background(), raw).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("%s: expected a JSON-RPC response, got %#v", name, env.server.HandleMessage(context.Background(), raw))
+	}
+	result, ok := response.Result.(mcp.CallToolResult)
+	if !ok {
+		t.Fatalf("%s: expected a tool result, got %#v", name, response.Result)
+	}
+	var text strings.Builder
+	for _, content := range result.Content {
+		if textContent, ok := content.(mcp.TextContent); ok {
+			text.WriteString(textContent.Text)
+		}
+	}
+	return text.String(), result.IsError
+}
+
+// callJSON invokes a tool that must succeed and decodes the JSON following its header line
+func (env *testEnv) callJSON(t *testing.T, name string, arguments map[string]any, v any) {
+	t.Helper()
+	text, isError := env.call(t, name, arguments)
+	if isError {
+		t.Fatalf("%s failed: %s", name, text)
+	}
+	_, body, _ := strings.Cut(text, "\n")
+	if err := json.Unmarshal([]byte(body), v); err != nil {
+		t.Fatalf("%s: failed to decode %q: %v", name, text, err)
+	}
+}
+
+// callError invokes a tool that must fail and returns its error details
+func (env *testEnv) callError(t *testing.T, name string, arguments map[string]any) *slack.ErrorDetails {
+	t.Helper()
+	text, isError := env.call(t, name, arguments)
+	if !isError {
+		t.Fatalf("%s: expected an error, got %s", name, text)
+	}
+	_, body, _ := strings.Cut(text, "\n")
+	var result struct {
+		Error *slack.ErrorDetails `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(body), &result); err != nil || result.Error == nil {
+		t.Fatalf("%s: error without details: %q", name, text)
+	}
+	return result.Error
+}
+
+func TestListChannels(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+
+	var page slack.ChannelListPage
+	env.callJSON(t, "slack_list_channels", nil, &page)
+	if got := channelNames(page.Channels); got != "general,random" {
+		t.Errorf("channels = %s, want general,random", got)
+	}
+	if page.Channels[0].Topic != "Company-wide announcements" || page.Channels[0].MemberCount != 4 {
+		t.Errorf("general = %+v", page.Channels[0])
+	}
+
+	page = slack.ChannelListPage{}
+	env.callJSON(t, "slack_list_channels", map[string]any{"include_archived": true, "name_prefix": "#gen"}, &page)
+	if got := channelNames(page.Channels); got != "general,general-archive" {
+		t.Errorf("channels = %s, want general,general-archive", got)
+	}
+
+	page = slack.ChannelListPage{}
+	env.callJSON(t, "slack_list_channels", map[string]any{"limit": 1}, &page)
+	if len(page.Channels) != 1 || page.NextCursor == "" {
+		t.Errorf("limit 1: %d channels, next cursor %q", len(page.Channels), page.NextCursor)
+	}
+}
+
+// channelNames joins the names of channels with commas
+func channelNames(channels []*slack.ChannelInfo) string {
+	names := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		names = append(names, channel.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestGetThreadReplies(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+
+	var replies slack.RenderedThreadReplies
+	env.callJSON(t, "slack_get_thread_replies", map[string]any{"thread_url": permalink(env.threadTS)}, &replies)
+	if replies.ChannelID != generalID || replies.ThreadTS != env.threadTS || len(replies.Messages) != 3 || replies.Truncated {
+		t.Fatalf("replies = %+v", replies)
+	}
+	if parent := replies.Messages[0]; parent.UserName != "bob" || parent.ReplyCount != 2 {
+		t.Errorf("parent = %+v", parent)
+	}
+	if reply := replies.Messages[2]; reply.Text != "ship it" || reply.ThreadTS != env.threadTS {
+		t.Errorf("last reply = %+v", reply)
+	}
+
+	replies = slack.RenderedThreadReplies{}
+	env.callJSON(t, "slack_get_thread_replies", map[string]any{"thread_url": permalink(env.threadTS), "limit": 2}, &replies)
+	if len(replies.Messages) != 2 || !replies.Truncated {
+		t.Errorf("limit 2: %d messages, truncated %t", len(replies.Messages), replies.Truncated)
+	}
+
+	text, isError := env.call(t, "slack_get_thread_replies", map[string]any{
+		"thread_url":    permalink(env.threadTS),
+		"output_format": "markdown_transcript",
+	})
+	if isError || !strings.Contains(text, "ship it") || !strings.Contains(text, "> ") {
+		t.Errorf("transcript = %q", text)
+	}
+
+	if details := env.callError(t, "slack_get_thread_replies", nil); details.Code != "invalid_argument" {
+		t.Errorf("without thread_url: code = %s", details.Code)
+	}
+}
+
+func TestPostMessage(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+
+	text, isError := env.call(t, "post_message", map[string]any{
+		"channel_id": "#general",
+		"text":       "**Release** is out, see [notes](https://example.com/notes)",
+		"format":     "markdown",
+	})
+	if isError {
+		t.Fatalf("post_message failed: %s", text)
+	}
+	messages := env.fake.Messages(generalID)
+	posted := messages[len(messages)-1]
+	if posted.Text != "*Release* is out, see <https://example.com/notes|notes>" || posted.BotID != fakeslack.BotID {
+		t.Errorf("posted %+v", posted.Msg)
+	}
+
+	text, isError = env.call(t, "post_message", map[string]any{
+		"channel_id": generalID,
+		"blocks":     []any{map[string]any{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": "from blocks"}}},
+	})
+	if isError {
+		t.Fatalf("post_message with blocks failed: %s", text)
+	}
+	messages = env.fake.Messages(generalID)
+	if posted := messages[len(messages)-1]; len(posted.Blocks.BlockSet) != 1 {
+		t.Errorf("posted %d blocks, want 1", len(posted.Blocks.BlockSet))
+	}
+
+	details := env.callError(t, "post_message", map[string]any{"channel_id": randomID, "text": "hi"})
+	if details.Code != "not_in_channel" || details.Retryable {
+		t.Errorf("posting outside the bot's channels: %+v", details)
+	}
+	if details := env.callError(t, "post_message", map[string]any{"channel_id": generalID}); details.Code != "invalid_argument" {
+		t.Errorf("without text: code = %s", details.Code)
+	}
+}
+
+func TestGetUsersProfile(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+
+	var profiles []*slack.UserProfileInfo
+	env.callJSON(t, "slack_get_users_profile", map[string]any{"user_ids": []any{aliceID, "bob@example.com"}}, &profiles)
+	if len(profiles) != 2 || profiles[0].Title != "Engineer" || profiles[1].ID != bobID {
+		t.Errorf("profiles = %+v", profiles)
+	}
+
+	details := env.callError(t, "slack_get_users_profile", map[string]any{"user_ids": []any{"U0NOBODY01"}})
+	if details.Code != "user_not_found" {
+		t.Errorf("unknown user: code = %s", details.Code)
+	}
+}
+
+func TestGetChannelHistory(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+
+	var history slack.RenderedChannelHistory
+	env.callJSON(t, "slack_get_channel_history", map[string]any{"channel_id": generalID}, &history)
+	if len(history.Messages) != 2 || history.HasMore {
+		t.Fatalf("history = %+v", history)
+	}
+	if hello := history.Messages[1]; hello.Text != "Hello @bob, see #random & the docs" || hello.UserName != "alice" {
+		t.Errorf("hello = %+v", hello)
+	}
+
+	var raw struct {
+		Messages []slackapi.Message `json:"messages"`
+	}
+	env.callJSON(t, "slack_get_channel_history", map[string]any{"channel_id": "general", "output_format": "raw", "limit": 1}, &raw)
+	if len(raw.Messages) != 1 || raw.Messages[0].Timestamp != env.threadTS {
+		t.Errorf("raw history = %+v", raw.Messages)
+	}
+
+	if details := env.callError(t, "slack_get_channel_history", map[string]any{"channel_id": randomID}); details.Code != "not_in_channel" {
+		t.Errorf("history of a channel the bot is not in: code = %s", details.Code)
+	}
+}
+
+func TestReplyToThread(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+
+	var posted slack.Message
+	env.callJSON(t, "slack_reply_to_thread", map[string]any{
+		"thread_url":      permalink(env.threadTS),
+		"text":            "rolling back",
+		"reply_broadcast": true,
+	}, &posted)
+	if posted.ThreadTimestamp != env.threadTS || posted.Permalink == "" {
+		t.Errorf("reply = %+v", posted)
+	}
+
+	messages := env.fake.Messages(generalID)
+	reply := messages[len(messages)-1]
+	if reply.Text != "rolling back" || reply.ThreadTimestamp != env.threadTS || reply.SubType != "thread_broadcast" {
+		t.Errorf("stored reply = %+v", reply.Msg)
+	}
+
+	details := env.callError(t, "slack_reply_to_thread", map[string]any{"channel_id": generalID, "thread_ts": "1.000001", "text": "x"})
+	if details.Code != "thread_not_found" {
+		t.Errorf("reply to a missing thread: code = %s", details.Code)
+	}
+}
+
+// scheduledResult is the output of slack_schedule_message
+type scheduledResult struct {
+	slack.ScheduledMessage
+	TimeZone string `json:"timezone"`
+}
+
+func TestScheduledMessages(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+
+	// a direct message defaults to the recipient's time zone
+	var dm scheduledResult
+	env.callJSON(t, "slack_schedule_message", map[string]any{"channel_id": aliceIMID, "post_at": "tomorrow 09:00", "text": "standup"}, &dm)
+	singapore, err := time.LoadLocation("Asia/Singapore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	year, month, day := time.Now().In(singapore).Date()
+	want := time.Date(year, month, day+1, 9, 0, 0, 0, singapore)
+	if dm.ID == "" || dm.Channel != aliceIMID || dm.TimeZone != "Asia/Singapore" || dm.PostAt != want.Unix() ||
+		dm.PostAtTime != want.Format(time.RFC3339) {
+		t.Errorf("scheduled %+v, want post_at %s", dm, want.Format(time.RFC3339))
+	}
+	if calls := env.fake.Calls("chat.scheduleMessage"); len(calls) != 1 || calls[0].Get("post_at") != strconv.FormatInt(want.Unix(), 10) {
+		t.Errorf("chat.scheduleMessage calls = %v", calls)
+	}
+
+	// channels default to the bot user's time zone, which is unset
+	var later, reply scheduledResult
+	env.callJSON(t, "slack_schedule_message", map[string]any{"channel_id": "#general", "post_at": "in 3d", "text": "retro"}, &later)
+	if later.TimeZone != "UTC" || later.PostAt < time.Now().Add(71*time.Hour).Unix() {
+		t.Errorf("scheduled %+v", later)
+	}
+	env.callJSON(t, "slack_schedule_message", map[string]any{
+		"channel_id": generalID, "post_at": "in 2h", "timezone": "Europe/London", "text": "done?", "thread_ts": env.threadTS,
+	}, &reply)
+	if reply.TimeZone != "Europe/London" || reply.ThreadTS != env.threadTS {
+		t.Errorf("scheduled reply %+v", reply)
+	}
+
+	var list slack.ListScheduledMessagesResponse
+	env.callJSON(t, "slack_list_scheduled_messages", map[string]any{"channel_id": "#general"}, &list)
+	if len(list.ScheduledMessages) != 2 || list.ScheduledMessages[0].ID != reply.ID || list.ScheduledMessages[1].Text != "retro" {
+		t.Errorf("scheduled in #general = %+v", list.ScheduledMessages)
+	}
+
+	text, isError := env.call(t, "slack_delete_scheduled_message", map[string]any{"channel_id": "#general", "scheduled_message_id": later.ID})
+	if isError {
+		t.Fatalf("slack_delete_scheduled_message failed: %s", text)
+	}
+	env.callJSON(t, "slack_list_scheduled_messages", map[string]any{}, &list)
+	if len(list.ScheduledMessages) != 2 || list.ScheduledMessages[0].ID != reply.ID || list.ScheduledMessages[1].ID != dm.ID {
+		t.Errorf("scheduled after the delete = %+v", list.ScheduledMessages)
+	}
+	details := env.callError(t, "slack_delete_scheduled_message", map[string]any{"channel_id": generalID, "scheduled_message_id": later.ID})
+	if details.Code != "invalid_scheduled_message_id" {
+		t.Errorf("deleting twice: code = %s", details.Code)
+	}
+
+	for _, invalid := range []struct {
+		arguments map[string]any
+		message   string
+	}{
+		{map[string]any{"post_at": "in 121d", "text": "hi"}, "more than 120 days ahead"},
+		{map[string]any{"post_at": "2020-01-01T09:00", "text": "hi"}, "in the past"},
+		{map[string]any{"post_at": "next week", "text": "hi"}, "unrecognized time"},
+		{map[string]any{"post_at": "in 2h", "timezone": "Mars/Base", "text": "hi"}, "unknown timezone"},
+		{map[string]any{"post_at": "in 2h"}, "text is required"},
+	} {
+		invalid.arguments["channel_id"] = generalID
+		details := env.callError(t, "slack_schedule_message", invalid.arguments)
+		if details.Code != "invalid_argument" || !strings.Contains(details.Message, invalid.message) {
+			t.Errorf("%v: %+v, want %q", invalid.arguments, details, invalid.message)
+		}
+	}
+	if len(env.fake.Calls("chat.scheduleMessage")) != 3 {
+		t.Errorf("invalid arguments reached Slack")
+	}
+}
+
+func TestReactions(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+	target := map[string]any{"message_url": permalink(env.helloTS), "reaction": ":thumbsup::skin-tone-2:"}
+
+	if text, isError := env.call(t, "slack_add_reaction", target); isError {
+		t.Fatalf("add reaction failed: %s", text)
+	}
+	var reactions []*slack.ReactionInfo
+	env.callJSON(t, "slack_get_reactions", map[string]any{"channel_id": generalID, "timestamp": env.helloTS}, &reactions)
+	if len(reactions) != 1 || reactions[0].Name != "thumbsup::skin-tone-2" || reactions[0].Users[0] != fakeslack.BotUserID {
+		t.Errorf("reactions = %+v", reactions)
+	}
+	if details := env.callError(t, "slack_add_reaction", target); details.Code != "already_reacted" {
+		t.Errorf("second add: code = %s", details.Code)
+	}
+
+	if text, isError := env.call(t, "slack_remove_reaction", target); isError {
+		t.Fatalf("remove reaction failed: %s", text)
+	}
+	reactions = nil
+	env.callJSON(t, "slack_get_reactions", map[string]any{"message_url": permalink(env.helloTS)}, &reactions)
+	if len(reactions) != 0 {
+		t.Errorf("reactions after removal = %+v", reactions)
+	}
+	if details := env.callError(t, "slack_remove_reaction", target); details.Code != "no_reaction" {
+		t.Errorf("second remove: code = %s", details.Code)
+	}
+}
+
+func TestSearchMessages(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+
+	var results slack.RenderedSearchResults
+	env.callJSON(t, "slack_search_messages", map[string]any{"query": "deploy", "workspace": "user"}, &results)
+	if results.Total != 2 || len(results.Matches) != 2 {
+		t.Fatalf("results = %+v", results)
+	}
+
+	results = slack.RenderedSearchResults{}
+	env.callJSON(t, "slack_search_messages", map[string]any{"query": "deploy in:#general", "workspace": "user"}, &results)
+	if results.Total != 1 || results.Matches[0].Channel != "general" || results.Matches[0].UserName != "bob" {
+		t.Errorf("in:#general results = %+v", results.Matches)
+	}
+
+	details := env.callError(t, "slack_search_messages", map[string]any{"query": "deploy"})
+	if details.Code != "missing_scope" || len(details.NeededScopes) != 1 || details.NeededScopes[0] != "search:read" {
+		t.Errorf("search with the bot token: %+v", details)
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+
+	var page slack.UserDirectoryPage
+	env.callJSON(t, "slack_list_users", nil, &page)
+	if got := userNames(page.Users); got != "owner,alice,bob,carol" {
+		t.Errorf("users = %s, want owner,alice,bob,carol", got)
+	}
+
+	page = slack.UserDirectoryPage{}
+	env.callJSON(t, "slack_list_users", map[string]any{"include_guests": false}, &page)
+	if got := userNames(page.Users); got != "owner,alice,bob" {
+		t.Errorf("users without guests = %s, want owner,alice,bob", got)
+	}
+
+	page = slack.UserDirectoryPage{}
+	env.callJSON(t, "slack_list_users", map[string]any{"include_bots": true, "include_guests": true, "tz": "europe/london"}, &page)
+	if got := userNames(page.Users); got != "bob" {
+		t.Errorf("users in Europe/London = %s, want bob", got)
+	}
+}
+
+// userNames joins the names of users with commas
+func userNames(users []*slack.UserProfileInfo) string {
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, user.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestFindUser(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+
+	var candidates []*slack.UserCandidate
+	env.callJSON(t, "slack_find_user", map[string]any{"query": "alice@example.com"}, &candidates)
+	if len(candidates) != 1 || candidates[0].ID != aliceID || candidates[0].MatchedOn != "email" {
+		t.Errorf("by email = %+v", candidates)
+	}
+
+	candidates = nil
+	env.callJSON(t, "slack_find_user", map[string]any{"query": "Bob Builder"}, &candidates)
+	if len(candidates) == 0 || candidates[0].ID != bobID {
+		t.Errorf("by name = %+v", candidates)
+	}
+}
+
+func TestFindChannel(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+
+	var matches []*slack.ChannelMatch
+	env.callJSON(t, "slack_find_channel", map[string]any{"query": "#general"}, &matches)
+	if len(matches) != 2 || matches[0].ID != generalID || !matches[0].Exact || matches[1].ID != archiveID {
+		t.Errorf("#general = %+v", matches)
+	}
+
+	matches = nil
+	env.callJSON(t, "slack_find_channel", map[string]any{"query": "@alice", "types": []any{"im"}}, &matches)
+	if len(matches) != 1 || matches[0].ID != aliceIMID {
+		t.Errorf("@alice = %+v", matches)
+	}
+
+	matches = nil
+	env.callJSON(t, "slack_find_channel", map[string]any{"query": "secret"}, &matches)
+	if len(matches) != 1 || matches[0].Type != "private_channel" {
+		t.Errorf("secret = %+v", matches)
+	}
+}
+
+func TestWorkspaces(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+
+	var workspaces []*workspace.Workspace
+	env.callJSON(t, "slack_list_workspaces", nil, &workspaces)
+	if len(workspaces) != 2 || workspaces[0].Alias != "bot" || !workspaces[0].Default || workspaces[1].TokenType != slack.TokenTypeUser {
+		t.Errorf("workspaces = %+v", workspaces)
+	}
+
+	var identity struct {
+		Workspace     string              `json:"workspace"`
+		UserID        string              `json:"user_id"`
+		BotID         string              `json:"bot_id"`
+		Scopes        []string            `json:"scopes"`
+		MissingScopes map[string][]string `json:"missing_scopes"`
+	}
+	env.callJSON(t, "slack_whoami", nil, &identity)
+	if identity.Workspace != "bot" || identity.UserID != fakeslack.BotUserID || identity.BotID != fakeslack.BotID {
+		t.Errorf("identity = %+v", identity)
+	}
+	if missing := identity.MissingScopes["slack_search_messages"]; len(missing) != 1 || missing[0] != "search:read" {
+		t.Errorf("missing scopes = %v", identity.MissingScopes)
+	}
+
+	if details := env.callError(t, "slack_whoami", map[string]any{"workspace": "nope"}); details.Code != "unknown_workspace" {
+		t.Errorf("unknown workspace: code = %s", details.Code)
+	}
+}
+
+func TestToolErrors(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+
+	env.fake.Fail("conversations.history", "channel_not_found")
+	details := env.callError(t, "slack_get_channel_history", map[string]any{"channel_id": generalID})
+	if details.Code != "channel_not_found" || !strings.Contains(details.Message, "slack_find_channel") {
+		t.Errorf("channel_not_found: %+v", details)
+	}
+
+	// writes are not retried, so the rate limit reaches the caller
+	env.fake.RateLimit("chat.postMessage", 30*time.Second)
+	details = env.callError(t, "post_message", map[string]any{"channel_id": generalID, "text": "hi"})
+	if details.Code != "ratelimited" || !details.Retryable || details.RetryAfterSeconds != 30 {
+		t.Errorf("ratelimited: %+v", details)
+	}
+
+	// scopes revoked after startup surface as Slack's missing_scope
+	env.fake.SetScopes(fakeslack.BotToken, "channels:history")
+	details = env.callError(t, "slack_get_reactions", map[string]any{"channel_id": generalID, "timestamp": env.helloTS})
+	if details.Code != "missing_scope" || len(details.NeededScopes) != 1 || details.NeededScopes[0] != "reactions:read" {
+		t.Errorf("missing_scope: %+v", details)
+	}
+}
+
+func TestReadRetriedAfterRateLimit(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+
+	env.fake.RateLimit("conversations.history", time.Second)
+	var history slack.RenderedChannelHistory
+	env.callJSON(t, "slack_get_channel_history", map[string]any{"channel_id": generalID}, &history)
+	if len(history.Messages) != 2 {
+		t.Errorf("history = %+v", history)
+	}
+	if calls := len(env.fake.Calls("conversations.history")); calls != 2 {
+		t.Errorf("conversations.history called %d times, want 2", calls)
+	}
+}
+
+func TestToolTimeout(t *testing.T) {
+	env := newTestEnv(t, 200*time.Millisecond)
+
+	// the retry would wait out a Retry-After past the tool's deadline
+	env.fake.RateLimit("conversations.history", 5*time.Second)
+	start := time.Now()
+	details := env.callError(t, "slack_get_channel_history", map[string]any{"channel_id": generalID})
+	if details.Code != "timeout" || !details.Retryable {
+		t.Errorf("timeout: %+v", details)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("call took %s, want it to end at the deadline", elapsed)
+	}
+}
+
+func TestToolsGatedOnScopes(t *testing.T) {
+	fake := fakeslack.NewServer()
+	defer fake.Close()
+	fake.SetScopes(fakeslack.BotToken, "channels:read", "chat:write")
+
+	workspaces, err := workspace.NewRegistry(context.Background(), &workspace.Config{
+		Workspaces: []workspace.WorkspaceConfig{{Alias: "bot", TeamID: fakeslack.TeamID, Token: fakeslack.BotToken, APIURL: fake.URL}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := newServer(workspaces, time.Minute, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	result := response.(mcp.JSONRPCResponse).Result.(mcp.ListToolsResult)
+	var names []string
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+	}
+	got := strings.Join(names, ",")
+	for _, want := range []string{"slack_list_channels", "post_message", "slack_reply_to_thread", "slack_whoami"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("tool %s not registered: %s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"slack_search_messages", "slack_add_reaction", "slack_list_users"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("tool %s registered without its scope: %s", unwanted, got)
+		}
+	}
+
+	if _, err := newServer(workspaces, time.Minute, map[string]time.Duration{"slack_nope": time.Second}, nil); err == nil {
+		t.Error("a timeout for an unknown tool was accepted")
+	}
+
+	// chat.scheduledMessages.list needs no scope, unlike scheduling and deleting
+	fake.SetScopes(fakeslack.BotToken, "users:read")
+	workspaces, err = workspace.NewRegistry(context.Background(), &workspace.Config{
+		Workspaces: []workspace.WorkspaceConfig{{Alias: "bot", TeamID: fakeslack.TeamID, Token: fakeslack.BotToken, APIURL: fake.URL}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err = newServer(workspaces, time.Minute, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	response = s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	names = nil
+	for _, tool := range response.(mcp.JSONRPCResponse).Result.(mcp.ListToolsResult).Tools {
+		names = append(names, tool.Name)
+	}
+	if !slices.Contains(names, "slack_list_scheduled_messages") || slices.Contains(names, "slack_schedule_message") {
+		t.Errorf("tools with users:read only: %v", names)
+	}
+}
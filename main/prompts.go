@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/shawnzhang/slack-go/pkg/slack"
+	"github.com/shawnzhang/slack-go/pkg/workspace"
+	slackapi "github.com/slack-go/slack"
+)
+
+const (
+	// promptMessageLimit caps the channel messages a prompt's transcript holds, not counting replies
+	promptMessageLimit = 500
+	// promptThreadLimit caps the threads whose replies a channel transcript includes
+	promptThreadLimit = 20
+)
+
+// addPrompts registers the prompt catalog when some workspace can read conversations. Each
+// prompt fetches the Slack content it is about, so its message already holds a transcript;
+// fetching times out after timeout, like tool calls.
+func addPrompts(s *server.MCPServer, workspaces *workspace.Registry, timeout time.Duration) {
+	if !workspaces.AnyGrants(historyScopes) {
+		log.Printf("skip prompts: no workspace grants %s", requirementsString(historyScopes))
+		return
+	}
+
+	// addPrompt runs a prompt handler with the timeout and the client of the workspace the
+	// request selects
+	addPrompt := func(prompt mcp.Prompt, handler func(ctx context.Context, slackClient *slack.Client, arguments map[string]string) (*mcp.GetPromptResult, error)) {
+		prompt.Arguments = append(prompt.Arguments, mcp.PromptArgument{
+			Name:        "workspace",
+			Description: "alias or team ID of the workspace to read (see slack_list_workspaces); the default workspace when omitted",
+		})
+		s.AddPrompt(prompt, func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			ws, err := workspaces.Get(request.Params.Arguments["workspace"])
+			if err != nil {
+				return nil, err
+			}
+			if missing := ws.Missing(historyScopes); len(missing) > 0 {
+				return nil, fmt.Errorf("prompt %s is not available in workspace %s: it lacks %s", prompt.Name, ws.Alias, requirementsString(missing))
+			}
+			for _, argument := range prompt.Arguments {
+				if argument.Required && strings.TrimSpace(request.Params.Arguments[argument.Name]) == "" {
+					return nil, fmt.Errorf("%s is required", argument.Name)
+				}
+			}
+			return handler(ctx, ws.Client, request.Params.Arguments)
+		})
+	}
+
+	sinceArgument := func(fallback string) mcp.PromptOption {
+		return mcp.WithArgument("since",
+			mcp.ArgumentDescription(fmt.Sprintf("start of the time window: a duration back from now (90m, 24h, 7d), today, yesterday, a YYYY-MM-DD date, an RFC 3339 time or a Slack timestamp (default %s)", fallback)),
+		)
+	}
+
+	addPrompt(mcp.NewPrompt("summarize_thread",
+		mcp.WithPromptDescription("Summarize a Slack thread: its outcome, decisions, open questions and action items"),
+		mcp.WithArgument("thread_url",
+			mcp.ArgumentDescription("URL of the thread's parent message or of one of its replies"),
+			mcp.RequiredArgument(),
+		),
+		mcp.WithArgument("focus",
+			mcp.ArgumentDescription("what the summary should concentrate on, e.g. the decision made or the customer impact"),
+		),
+	), func(ctx context.Context, slackClient *slack.Client, arguments map[string]string) (*mcp.GetPromptResult, error) {
+		thread, err := slackClient.GetThreadReplies(ctx, arguments["thread_url"], resourceThreadLimit)
+		if err != nil {
+			return nil, describedError("failed to get thread replies", err)
+		}
+		transcript := slack.FormatTranscript(slackClient.RenderMessages(ctx, thread.Messages))
+		if thread.Truncated {
+			transcript += fmt.Sprintf("\n_thread truncated after %d messages_\n", len(thread.Messages))
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Summarize the Slack thread below (channel %s, thread %s).\n\n", thread.ChannelID, thread.ThreadTS)
+		b.WriteString("Start with a one-sentence TL;DR, then list the decisions made, the open questions and the action items with their owners. ")
+		b.WriteString("Refer to people by name and keep it short enough to paste back into the thread.\n")
+		if focus := strings.TrimSpace(arguments["focus"]); focus != "" {
+			fmt.Fprintf(&b, "Concentrate on: %s\n", focus)
+		}
+		fmt.Fprintf(&b, "\n%s", transcript)
+		return promptResult("Summary of a Slack thread", b.String()), nil
+	})
+
+	addPrompt(mcp.NewPrompt("standup",
+		mcp.WithPromptDescription("Draft a standup update from what was posted in a channel, threads included"),
+		mcp.WithArgument("channel",
+			mcp.ArgumentDescription("channel ID or name, e.g. C01234567 or #team-eng"),
+			mcp.RequiredArgument(),
+		),
+		sinceArgument("yesterday"),
+	), func(ctx context.Context, slackClient *slack.Client, arguments map[string]string) (*mcp.GetPromptResult, error) {
+		window, err := fetchChannelWindow(ctx, slackClient, arguments["channel"], arguments["since"], "yesterday", true)
+		if err != nil {
+			return nil, err
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Draft a standup update from the Slack messages posted in %s since %s.\n\n", window.label(), window.sinceTime())
+		b.WriteString("Group it under Done, In progress and Blockers, one bullet per item with the person it belongs to. ")
+		b.WriteString("Only use what the messages say; leave a section empty rather than guessing.\n\n")
+		b.WriteString(window.transcript())
+		return promptResult("Standup draft for "+window.label(), b.String()), nil
+	})
+
+	addPrompt(mcp.NewPrompt("triage_questions",
+		mcp.WithPromptDescription("Triage the questions asked in a channel that nobody has replied to in a thread"),
+		mcp.WithArgument("channel",
+			mcp.ArgumentDescription("channel ID or name, e.g. C01234567 or #help-desk"),
+			mcp.RequiredArgument(),
+		),
+		sinceArgument("7d"),
+	), func(ctx context.Context, slackClient *slack.Client, arguments map[string]string) (*mcp.GetPromptResult, error) {
+		window, err := fetchChannelWindow(ctx, slackClient, arguments["channel"], arguments["since"], "7d", false)
+		if err != nil {
+			return nil, err
+		}
+		var questions []*slack.RenderedMessage
+		for _, msg := range window.messages {
+			if msg.ThreadTS == "" && msg.ReplyCount == 0 && msg.SubType == "" && strings.Contains(msg.Text, "?") {
+				questions = append(questions, msg)
+			}
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Triage the unanswered questions asked in %s since %s.\n\n", window.label(), window.sinceTime())
+		b.WriteString("For each question, say whether it is still open (it may have been answered in the channel rather than in a thread), ")
+		b.WriteString("how urgent it is, and who or what could answer it. Order them most urgent first and cite each by its ts so it can be replied to with slack_reply_to_thread.\n\n")
+		if len(questions) == 0 {
+			b.WriteString("## Unanswered questions\n\nNo message with a question mark is without thread replies.\n\n")
+		} else {
+			fmt.Fprintf(&b, "## Unanswered questions (%d)\n\n%s\n", len(questions), slack.FormatTranscript(questions))
+		}
+		b.WriteString("## Channel transcript\n\n")
+		b.WriteString(window.transcript())
+		return promptResult("Unanswered questions in "+window.label(), b.String()), nil
+	})
+
+	addPrompt(mcp.NewPrompt("incident_update",
+		mcp.WithPromptDescription("Write a status update for an incident from its channel or thread"),
+		mcp.WithArgument("channel",
+			mcp.ArgumentDescription("incident channel ID or name; ignored when thread_url is given"),
+		),
+		mcp.WithArgument("thread_url",
+			mcp.ArgumentDescription("URL of the incident thread, to read it instead of a channel"),
+		),
+		sinceArgument("24h"),
+		mcp.WithArgument("status",
+			mcp.ArgumentDescription("current incident status: investigating, identified, monitoring or resolved (inferred from the messages when omitted)"),
+		),
+		mcp.WithArgument("audience",
+			mcp.ArgumentDescription("who the update is for, e.g. engineering, executives or customers (default: internal stakeholders)"),
+		),
+	), func(ctx context.Context, slackClient *slack.Client, arguments map[string]string) (*mcp.GetPromptResult, error) {
+		status := strings.ToLower(strings.TrimSpace(arguments["status"]))
+		if status != "" && !slices.Contains([]string{"investigating", "identified", "monitoring", "resolved"}, status) {
+			return nil, fmt.Errorf("invalid status %q, expected investigating, identified, monitoring or resolved", arguments["status"])
+		}
+		audience := firstNonBlank(arguments["audience"], "internal stakeholders")
+
+		var source, transcript string
+		if threadURL := strings.TrimSpace(arguments["thread_url"]); threadURL != "" {
+			thread, err := slackClient.GetThreadReplies(ctx, threadURL, resourceThreadLimit)
+			if err != nil {
+				return nil, describedError("failed to get thread replies", err)
+			}
+			source = fmt.Sprintf("the incident thread %s in channel %s", thread.ThreadTS, thread.ChannelID)
+			transcript = slack.FormatTranscript(slackClient.RenderMessages(ctx, thread.Messages))
+			if thread.Truncated {
+				transcript += fmt.Sprintf("\n_thread truncated after %d messages_\n", len(thread.Messages))
+			}
+		} else if strings.TrimSpace(arguments["channel"]) != "" {
+			window, err := fetchChannelWindow(ctx, slackClient, arguments["channel"], arguments["since"], "24h", true)
+			if err != nil {
+				return nil, err
+			}
+			source = fmt.Sprintf("the incident channel %s since %s", window.label(), window.sinceTime())
+			transcript = window.transcript()
+		} else {
+			return nil, errors.New("channel or thread_url is required")
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Write an incident status update for %s from the Slack messages of %s.\n\n", audience, source)
+		if status != "" {
+			fmt.Fprintf(&b, "The incident's status is %s.\n", status)
+		} else {
+			b.WriteString("Infer the incident's status (investigating, identified, monitoring or resolved) from the messages.\n")
+		}
+		b.WriteString("Use these sections: Status, Impact, What we know, Timeline (times in UTC), Next steps and Next update. ")
+		b.WriteString("State only what the messages support, say plainly what is still unknown, and leave out internal chatter and names unless the audience is engineering.\n\n")
+		b.WriteString(transcript)
+		return promptResult("Incident update from "+source, b.String()), nil
+	})
+}
+
+// promptResult returns text as the single user message of a prompt
+func promptResult(description, text string) *mcp.GetPromptResult {
+	return mcp.NewGetPromptResult(description, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+	})
+}
+
+// channelWindow is the messages of a channel within a time window, oldest first, each
+// fetched thread's replies following their parent
+type channelWindow struct {
+	channel   string
+	channelID string
+	oldest    string
+	messages  []*slack.RenderedMessage
+	// truncated is set when the window holds more than promptMessageLimit messages
+	truncated bool
+}
+
+// fetchChannelWindow reads the messages channel got since since (fallback when empty). With
+// threads, the replies of the first promptThreadLimit threads are read too.
+func fetchChannelWindow(ctx context.Context, slackClient *slack.Client, channel, since, fallback string, threads bool) (*channelWindow, error) {
+	oldest, err := promptSince(firstNonBlank(since, fallback), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	channelID, err := slackClient.ResolveChannelID(ctx, channel)
+	if err != nil {
+		return nil, describedError("failed to resolve channel", err)
+	}
+	window := &channelWindow{channel: strings.TrimSpace(channel), channelID: channelID, oldest: oldest}
+
+	var history []slackapi.Message
+	cursor := ""
+	for {
+		page, err := slackClient.GetChannelHistory(ctx, &slack.GetChannelHistoryParameters{
+			ChannelID: channelID,
+			Oldest:    oldest,
+			Limit:     200,
+			Cursor:    cursor,
+		})
+		if err != nil {
+			return nil, describedError("failed to get channel history", err)
+		}
+		history = append(history, page.Messages...)
+		if len(history) >= promptMessageLimit {
+			window.truncated = len(history) > promptMessageLimit || page.HasMore
+			history = history[:min(len(history), promptMessageLimit)]
+			break
+		}
+		if !page.HasMore || page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	// conversations.history returns the newest message first; transcripts read oldest first
+	slices.Reverse(history)
+
+	var messages []slackapi.Message
+	threadCount := 0
+	for _, msg := range history {
+		messages = append(messages, msg)
+		if !threads || msg.ReplyCount == 0 || threadCount >= promptThreadLimit {
+			continue
+		}
+		threadCount++
+		thread, err := slackClient.GetThread(ctx, channelID, msg.Timestamp, resourceThreadLimit)
+		if err != nil {
+			return nil, describedError("failed to get thread replies", err)
+		}
+		for _, reply := range thread.Messages {
+			if reply.Timestamp != msg.Timestamp {
+				messages = append(messages, reply)
+			}
+		}
+	}
+	window.messages = slackClient.RenderMessages(ctx, messages)
+	return window, nil
+}
+
+// label names the window's channel as the caller did, with its ID
+func (w *channelWindow) label() string {
+	if w.channel == w.channelID {
+		return w.channelID
+	}
+	return fmt.Sprintf("%s (%s)", w.channel, w.channelID)
+}
+
+// sinceTime formats the start of the window as an RFC 3339 UTC time
+func (w *channelWindow) sinceTime() string {
+	seconds, _, _ := strings.Cut(w.oldest, ".")
+	if unix, err := strconv.ParseInt(seconds, 10, 64); err == nil {
+		return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+	}
+	return w.oldest
+}
+
+// transcript formats the window's messages as a Markdown transcript
+func (w *channelWindow) transcript() string {
+	if len(w.messages) == 0 {
+		return "_no messages in this time window_\n"
+	}
+	transcript := slack.FormatTranscript(w.messages)
+	if w.truncated {
+		transcript = fmt.Sprintf("_only the latest %d messages of the window_\n\n%s", promptMessageLimit, transcript)
+	}
+	return transcript
+}
+
+// promptSince converts the start of a prompt's time window into a Slack timestamp. Besides the
+// formats GetChannelHistory accepts, it takes durations back from now (90m, 24h, 7d), today and
+// yesterday (UTC midnights).
+func promptSince(value string, now time.Time) (string, error) {
+	value = strings.TrimSpace(value)
+	utc := now.UTC()
+	midnight := time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
+	switch {
+	case strings.EqualFold(value, "today"):
+		return strconv.FormatInt(midnight.Unix(), 10) + ".000000", nil
+	case strings.EqualFold(value, "yesterday"):
+		return strconv.FormatInt(midnight.AddDate(0, 0, -1).Unix(), 10) + ".000000", nil
+	}
+
+	var back time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return "", fmt.Errorf("invalid since %q, expected a positive number of days like 7d", value)
+		}
+		back = time.Duration(n) * 24 * time.Hour
+	} else if d, err := time.ParseDuration(value); err == nil {
+		if d <= 0 {
+			return "", fmt.Errorf("invalid since %q, expected a positive duration", value)
+		}
+		back = d
+	} else {
+		// a date, time or Slack timestamp, which GetChannelHistory validates
+		return value, nil
+	}
+	return strconv.FormatInt(now.Add(-back).Unix(), 10) + ".000000", nil
+}
+
+// firstNonBlank returns the first of values that is not blank, trimmed
+func firstNonBlank(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
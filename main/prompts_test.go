@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// getPrompt gets a prompt and returns the text of its single message, or the JSON-RPC error message
+func getPrompt(t *testing.T, c *stdioClient, name string, arguments map[string]string) (string, string) {
+	t.Helper()
+	response := c.request("prompts/get", map[string]any{"name": name, "arguments": arguments})
+	if response.Error != nil {
+		var rpcErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(response.Error, &rpcErr)
+		return "", rpcErr.Message
+	}
+	var result struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(response.Result, &result); err != nil || len(result.Messages) != 1 {
+		t.Fatalf("%s: unexpected result %s", name, response.Result)
+	}
+	if msg := result.Messages[0]; msg.Role != "user" || msg.Content.Type != "text" {
+		t.Errorf("%s: %s message of type %s", name, msg.Role, msg.Content.Type)
+	}
+	return result.Messages[0].Content.Text, ""
+}
+
+func TestPrompts(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+	questionTS := env.fake.AddMessage(generalID, message(aliceID, "who owns the billing alerts?", ""))
+	env.fake.AddMessage(generalID, message(bobID, "is the deploy done?", env.threadTS))
+	c := startStdio(t, env)
+
+	text, message := getPrompt(t, c, "summarize_thread", map[string]string{"thread_url": permalink(env.threadTS), "focus": "the rollout"})
+	if message != "" {
+		t.Fatal(message)
+	}
+	for _, want := range []string{"Concentrate on: the rollout", "**bob**", "> **alice**", "the deploy is done", "ship it"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("summarize_thread lacks %q:\n%s", want, text)
+		}
+	}
+
+	text, message = getPrompt(t, c, "standup", map[string]string{"channel": "#general", "since": "2025-03-01"})
+	if message != "" {
+		t.Fatal(message)
+	}
+	// threads follow their parent, oldest message first
+	hello, deploy, reply := strings.Index(text, "Hello @bob"), strings.Index(text, "the deploy is done"), strings.Index(text, "> great, thanks")
+	if !strings.Contains(text, "#general ("+generalID+") since 2025-03-01") || hello < 0 || hello > deploy || deploy > reply {
+		t.Errorf("standup transcript out of order:\n%s", text)
+	}
+
+	text, message = getPrompt(t, c, "triage_questions", map[string]string{"channel": generalID, "since": "2025-03-01"})
+	if message != "" {
+		t.Fatal(message)
+	}
+	questions, _, _ := strings.Cut(text, "## Channel transcript")
+	if !strings.Contains(questions, "## Unanswered questions (1)") || !strings.Contains(questions, "ts "+questionTS) ||
+		strings.Contains(questions, "is the deploy done?") {
+		t.Errorf("triage_questions lists the wrong questions:\n%s", questions)
+	}
+
+	text, message = getPrompt(t, c, "incident_update", map[string]string{"thread_url": permalink(env.threadTS), "status": "Monitoring", "audience": "executives"})
+	if message != "" {
+		t.Fatal(message)
+	}
+	if !strings.Contains(text, "for executives") || !strings.Contains(text, "status is monitoring") || !strings.Contains(text, "ship it") {
+		t.Errorf("incident_update:\n%s", text)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		arguments map[string]string
+		want      string
+	}{
+		{"summarize_thread", nil, "thread_url is required"},
+		{"standup", map[string]string{"channel": "general", "since": "0d"}, "invalid since"},
+		{"standup", map[string]string{"channel": "general", "since": "last week"}, "invalid oldest"},
+		{"standup", map[string]string{"channel": randomID}, "(not_in_channel)"},
+		{"incident_update", map[string]string{"status": "resolved"}, "channel or thread_url is required"},
+		{"incident_update", map[string]string{"channel": "general", "status": "fixed"}, "invalid status"},
+		{"triage_questions", map[string]string{"channel": "general", "workspace": "nope"}, "nope"},
+	} {
+		if _, message := getPrompt(t, c, tc.name, tc.arguments); !strings.Contains(message, tc.want) {
+			t.Errorf("%s %v: error %q, want %q", tc.name, tc.arguments, message, tc.want)
+		}
+	}
+}
+
+func TestPromptSince(t *testing.T) {
+	now := time.Date(2025, 3, 2, 1, 30, 0, 0, time.FixedZone("UTC+8", 8*3600))
+	for value, want := range map[string]string{
+		"90m":        "1740844800.000000",
+		"2d":         "1740677400.000000",
+		"today":      "1740787200.000000",
+		"Yesterday":  "1740700800.000000",
+		"2025-02-01": "2025-02-01",
+	} {
+		got, err := promptSince(value, now)
+		if err != nil || got != want {
+			t.Errorf("promptSince(%q) = %q, %v, want %q", value, got, err, want)
+		}
+	}
+	if _, err := promptSince("-1h", now); err == nil {
+		t.Error("a negative duration was accepted")
+	}
+}
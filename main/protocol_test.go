@@ -0,0 +1,378 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shawnzhang/slack-go/pkg/transport"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/protocol")
+
+// responseTimeout bounds how long the harness waits for the response to a request
+const responseTimeout = 10 * time.Second
+
+// stdioClient is an MCP client talking to the server over an in-memory stdio pipe, the way
+// an IDE talks to the slack-mcp binary
+type stdioClient struct {
+	t      *testing.T
+	in     io.Writer
+	lines  chan []byte
+	nextID int
+	// notifications receives the notifications the server sends
+	notifications chan jsonrpcNotification
+}
+
+// startStdio serves env's MCP server over stdio pipes and returns a client connected to them
+func startStdio(t *testing.T, env *testEnv) *stdioClient {
+	t.Helper()
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- transport.ServeStdio(context.Background(), env.server, env.subscriptions, serverIn, serverOut)
+	}()
+
+	c := &stdioClient{
+		t:             t,
+		in:            clientOut,
+		lines:         make(chan []byte, 16),
+		notifications: make(chan jsonrpcNotification, 64),
+	}
+	go func() {
+		defer close(c.lines)
+		reader := bufio.NewReader(clientIn)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				return
+			}
+			c.lines <- line
+		}
+	}()
+
+	t.Cleanup(func() {
+		clientOut.Close()
+		clientIn.Close()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("stdio server: %v", err)
+			}
+		case <-time.After(responseTimeout):
+			t.Error("stdio server did not stop after stdin was closed")
+		}
+	})
+	return c
+}
+
+// jsonrpcResponse is a response read from the server
+type jsonrpcResponse struct {
+	ID     *int            `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  json.RawMessage `json:"error"`
+}
+
+// jsonrpcNotification is a notification read from the server
+type jsonrpcNotification struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// send writes one JSON-RPC message to the server
+func (c *stdioClient) send(message map[string]any) {
+	c.t.Helper()
+	message["jsonrpc"] = "2.0"
+	data, err := json.Marshal(message)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err := c.in.Write(append(data, '\n')); err != nil {
+		c.t.Fatalf("failed to write to the server: %v", err)
+	}
+}
+
+// notify sends a notification, which gets no response
+func (c *stdioClient) notify(method string) {
+	c.t.Helper()
+	c.send(map[string]any{"method": method})
+}
+
+// request sends a request and returns its response; notifications read meanwhile are
+// queued on c.notifications
+func (c *stdioClient) request(method string, params any) jsonrpcResponse {
+	c.t.Helper()
+	c.nextID++
+	id := c.nextID
+	message := map[string]any{"id": id, "method": method}
+	if params != nil {
+		message["params"] = params
+	}
+	c.send(message)
+
+	timeout := time.After(responseTimeout)
+	for {
+		select {
+		case line, ok := <-c.lines:
+			if !ok {
+				c.t.Fatalf("%s: the server closed stdout", method)
+			}
+			var response jsonrpcResponse
+			if err := json.Unmarshal(line, &response); err != nil {
+				c.t.Fatalf("%s: invalid message %q: %v", method, line, err)
+			}
+			if response.ID == nil {
+				var notification jsonrpcNotification
+				json.Unmarshal(line, &notification)
+				select {
+				case c.notifications <- notification:
+				default:
+				}
+				continue
+			}
+			if *response.ID == id {
+				return response
+			}
+		case <-timeout:
+			c.t.Fatalf("%s: no response after %s", method, responseTimeout)
+		}
+	}
+}
+
+// waitNotification returns the next notification with the method, or false when none
+// arrives within timeout
+func (c *stdioClient) waitNotification(method string, timeout time.Duration) (jsonrpcNotification, bool) {
+	c.t.Helper()
+	deadline := time.After(timeout)
+	for {
+		select {
+		case notification := <-c.notifications:
+			if notification.Method == method {
+				return notification, true
+			}
+			continue
+		default:
+		}
+		select {
+		case line, ok := <-c.lines:
+			if !ok {
+				c.t.Fatalf("waiting for %s: the server closed stdout", method)
+			}
+			var notification jsonrpcNotification
+			if err := json.Unmarshal(line, &notification); err != nil {
+				c.t.Fatalf("invalid message %q: %v", line, err)
+			}
+			if notification.Method == method {
+				return notification, true
+			}
+		case <-deadline:
+			return jsonrpcNotification{}, false
+		}
+	}
+}
+
+// callTool calls a tool and returns its result rendered for a golden file
+func (c *stdioClient) callTool(name string, arguments map[string]any) string {
+	c.t.Helper()
+	if arguments == nil {
+		arguments = map[string]any{}
+	}
+	response := c.request("tools/call", map[string]any{"name": name, "arguments": arguments})
+	if response.Error != nil {
+		return "error: " + indentJSON(c.t, response.Error)
+	}
+
+	var result struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	}
+	if err := json.Unmarshal(response.Result, &result); err != nil {
+		c.t.Fatalf("%s: invalid tool result %s: %v", name, response.Result, err)
+	}
+	var out strings.Builder
+	if result.IsError {
+		out.WriteString("is_error: true\n")
+	}
+	// tools answer with a header line followed by JSON, which is indented to keep diffs readable
+	for _, content := range result.Content {
+		header, body, found := strings.Cut(content.Text, "\n")
+		if found && json.Valid([]byte(body)) {
+			out.WriteString(strings.TrimSpace(header) + "\n" + indentJSON(c.t, []byte(body)))
+		} else {
+			out.WriteString(content.Text + "\n")
+		}
+	}
+	return out.String()
+}
+
+// indentJSON returns data indented, with a trailing newline
+func indentJSON(t *testing.T, data []byte) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	return out.String() + "\n"
+}
+
+// sortedJSON indents a result after sorting its list field by the key of the items
+func sortedJSON(t *testing.T, result json.RawMessage, field, key string) string {
+	t.Helper()
+	var fields map[string]json.RawMessage
+	var items []map[string]any
+	if err := json.Unmarshal(result, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(fields[field], &items); err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i][key].(string) < items[j][key].(string)
+	})
+	sorted, err := json.Marshal(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields[field] = sorted
+	data, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return indentJSON(t, data)
+}
+
+// checkGolden compares got with testdata/protocol/<name>.golden, rewriting the file with -update
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "protocol", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test ./main -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from the golden file (run go test ./main -update if the change is intended)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// TestProtocol drives a whole session over stdio: initialize, tools/list, the resources and a
+// tools/call of every tool, in the order a client would use them, against the fake workspace
+func TestProtocol(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+	c := startStdio(t, env)
+
+	initialize := c.request("initialize", map[string]any{
+		"protocolVersion": "2024-11-05",
+		"clientInfo":      map[string]any{"name": "protocol-test", "version": "1.0.0"},
+		"capabilities":    map[string]any{},
+	})
+	checkGolden(t, "initialize", indentJSON(t, initialize.Result))
+	c.notify("notifications/initialized")
+
+	tools := c.request("tools/list", nil)
+	checkGolden(t, "tools_list", indentJSON(t, tools.Result))
+
+	// mcp-go lists resources in map order, so they are sorted by URI for the golden files
+	resources := c.request("resources/list", nil)
+	checkGolden(t, "resources_list", sortedJSON(t, resources.Result, "resources", "uri"))
+	templates := c.request("resources/templates/list", nil)
+	checkGolden(t, "resources_templates_list", sortedJSON(t, templates.Result, "resourceTemplates", "uriTemplate"))
+	thread := c.request("resources/read", map[string]any{"uri": "slack://thread/" + generalID + "/" + env.threadTS})
+	checkGolden(t, "resources_read_thread", indentJSON(t, thread.Result))
+	prompts := c.request("prompts/list", nil)
+	checkGolden(t, "prompts_list", sortedJSON(t, prompts.Result, "prompts", "name"))
+	standup := c.request("prompts/get", map[string]any{
+		"name":      "standup",
+		"arguments": map[string]any{"channel": "general", "since": "2025-03-01"},
+	})
+	checkGolden(t, "prompts_get_standup", indentJSON(t, standup.Result))
+
+	calls := []struct {
+		golden    string
+		tool      string
+		arguments map[string]any
+	}{
+		{"list_channels", "slack_list_channels", map[string]any{"limit": 100}},
+		{"find_channel", "slack_find_channel", map[string]any{"query": "#general"}},
+		{"channel_history", "slack_get_channel_history", map[string]any{"channel_id": "#general"}},
+		{"channel_history_transcript", "slack_get_channel_history", map[string]any{"channel_id": generalID, "output_format": "markdown_transcript"}},
+		{"thread_replies", "slack_get_thread_replies", map[string]any{"thread_url": permalink(env.threadTS)}},
+		{"post_message", "post_message", map[string]any{"channel_id": "general", "text": "**Release 1.2** is out", "format": "markdown"}},
+		{"post_message_not_in_channel", "post_message", map[string]any{"channel_id": "#random", "text": "hi"}},
+		{"reply_to_thread", "slack_reply_to_thread", map[string]any{"channel_id": generalID, "thread_ts": env.threadTS, "text": "verified on staging"}},
+		{"add_reaction", "slack_add_reaction", map[string]any{"message_url": permalink(env.helloTS), "reaction": "eyes"}},
+		{"get_reactions", "slack_get_reactions", map[string]any{"message_url": permalink(env.helloTS)}},
+		{"remove_reaction", "slack_remove_reaction", map[string]any{"channel_id": generalID, "timestamp": env.helloTS, "reaction": "eyes"}},
+		{"search_messages", "slack_search_messages", map[string]any{"query": "deploy", "workspace": "user"}},
+		{"search_messages_missing_scope", "slack_search_messages", map[string]any{"query": "deploy"}},
+		{"get_users_profile", "slack_get_users_profile", map[string]any{"user_ids": []string{aliceID, bobID}}},
+		{"list_users", "slack_list_users", map[string]any{"include_guests": false}},
+		{"find_user", "slack_find_user", map[string]any{"query": "@bob"}},
+		{"list_workspaces", "slack_list_workspaces", nil},
+		{"missing_argument", "slack_get_thread_replies", nil},
+		{"unknown_tool", "slack_post_message", map[string]any{"channel_id": generalID, "text": "hi"}},
+	}
+	for _, call := range calls {
+		checkGolden(t, "call_"+call.golden, c.callTool(call.tool, call.arguments))
+	}
+}
+
+// TestReadmeListsEveryTool keeps the tools documented in the README in step with the tools
+// the server registers
+func TestReadmeListsEveryTool(t *testing.T) {
+	readme, err := os.ReadFile(filepath.Join("..", "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, tools, _ := strings.Cut(string(readme), "\n## Tools\n")
+	tools, _, _ = strings.Cut(tools, "\n## ")
+	var documented []string
+	for _, match := range regexp.MustCompile("(?m)^[0-9]+\\. (.+)$").FindAllStringSubmatch(tools, -1) {
+		for _, name := range regexp.MustCompile("`([a-z_]+)`").FindAllStringSubmatch(match[1], -1) {
+			documented = append(documented, name[1])
+		}
+	}
+	sort.Strings(documented)
+
+	env := newTestEnv(t, time.Minute)
+	response := startStdio(t, env).request("tools/list", nil)
+	var result struct {
+		Tools []struct {
+			Name string `json:"name"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(response.Result, &result); err != nil {
+		t.Fatal(err)
+	}
+	var registered []string
+	for _, tool := range result.Tools {
+		registered = append(registered, tool.Name)
+	}
+	sort.Strings(registered)
+
+	if strings.Join(documented, ",") != strings.Join(registered, ",") {
+		t.Errorf("README documents tools\n%v\nbut the server registers\n%v", documented, registered)
+	}
+}
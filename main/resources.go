@@ -0,0 +1,426 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/shawnzhang/slack-go/pkg/events"
+	"github.com/shawnzhang/slack-go/pkg/slack"
+	"github.com/shawnzhang/slack-go/pkg/transport"
+	"github.com/shawnzhang/slack-go/pkg/workspace"
+)
+
+// URIs of the resources; they read the default workspace
+const (
+	channelsURI            = "slack://channels"
+	channelHistoryTemplate = "slack://channel/{id}/history"
+	threadTemplate         = "slack://thread/{channel}/{ts}"
+	userTemplate           = "slack://user/{id}"
+)
+
+const (
+	// resourceHistoryLimit is how many of a channel's latest messages its history resource holds
+	resourceHistoryLimit = 100
+	// resourceThreadLimit is how many messages of a thread its resource holds
+	resourceThreadLimit = 1000
+)
+
+// historyScopes are the scopes reading any kind of conversation needs
+var historyScopes = []workspace.Requirement{{"channels:history", "groups:history", "im:history", "mpim:history"}}
+
+// resourceScopes are the OAuth scopes each resource needs. A resource is registered when the
+// default workspace's token grants them.
+var resourceScopes = map[string][]workspace.Requirement{
+	channelsURI:            {{"channels:read"}},
+	channelHistoryTemplate: historyScopes,
+	threadTemplate:         historyScopes,
+	userTemplate:           {{"users:read"}},
+}
+
+// addResources registers the resources the default workspace's token allows. Reads time out
+// after timeout, like tool calls.
+func addResources(s *server.MCPServer, workspaces *workspace.Registry, timeout time.Duration) {
+	ws, err := workspaces.Get("")
+	if err != nil {
+		log.Printf("skip resources: %v", err)
+		return
+	}
+	slackClient := ws.Client
+	granted := func(uri string) bool {
+		if missing := ws.Missing(resourceScopes[uri]); len(missing) > 0 {
+			log.Printf("skip resource %s: workspace %s lacks %s", uri, ws.Alias, requirementsString(missing))
+			return false
+		}
+		return true
+	}
+	// read runs a resource handler with the timeout
+	type readFunc = func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error)
+	read := func(handler readFunc) readFunc {
+		return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return handler(ctx, request)
+		}
+	}
+
+	if granted(channelsURI) {
+		s.AddResource(mcp.NewResource(channelsURI, "Channels",
+			mcp.WithResourceDescription(fmt.Sprintf("Public channels of workspace %s, up to 200; page through the rest with slack_list_channels", ws.Alias)),
+			mcp.WithMIMEType("application/json"),
+		), read(func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			result, err := slackClient.ListChannels(ctx, &slack.ListChannelsParameters{Limit: 200})
+			if err != nil {
+				return nil, describedError("failed to get channel list", err)
+			}
+			page := &slack.ChannelListPage{
+				Channels:   make([]*slack.ChannelInfo, 0, len(result.Channels)),
+				NextCursor: result.ResponseMetadata.NextCursor,
+			}
+			for i := range result.Channels {
+				page.Channels = append(page.Channels, slack.NewChannelInfo(&result.Channels[i]))
+			}
+			return jsonResource(request.Params.URI, page)
+		}))
+	}
+
+	if granted(channelHistoryTemplate) {
+		s.AddResourceTemplate(mcp.NewResourceTemplate(channelHistoryTemplate, "Channel history",
+			mcp.WithTemplateDescription(fmt.Sprintf("The latest %d messages of a channel (ID or name), newest first, with mentions resolved; subscribe to be notified of new messages", resourceHistoryLimit)),
+			mcp.WithTemplateMIMEType("application/json"),
+		), read(func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			channelID, err := slackClient.ResolveChannelID(ctx, templateArgument(request, "id"))
+			if err != nil {
+				return nil, describedError("failed to resolve channel", err)
+			}
+			result, err := slackClient.GetChannelHistory(ctx, &slack.GetChannelHistoryParameters{
+				ChannelID: channelID,
+				Limit:     resourceHistoryLimit,
+			})
+			if err != nil {
+				return nil, describedError("failed to get channel history", err)
+			}
+			return jsonResource(request.Params.URI, &slack.RenderedChannelHistory{
+				Messages:   slackClient.RenderMessages(ctx, result.Messages),
+				HasMore:    result.HasMore,
+				NextCursor: result.NextCursor,
+			})
+		}))
+	}
+
+	if granted(threadTemplate) {
+		s.AddResourceTemplate(mcp.NewResourceTemplate(threadTemplate, "Thread",
+			mcp.WithTemplateDescription("The messages of a thread, parent first, with mentions resolved; ts is the parent message's timestamp. Subscribe to be notified of new replies"),
+			mcp.WithTemplateMIMEType("application/json"),
+		), read(func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			channelID, err := slackClient.ResolveChannelID(ctx, templateArgument(request, "channel"))
+			if err != nil {
+				return nil, describedError("failed to resolve channel", err)
+			}
+			result, err := slackClient.GetThread(ctx, channelID, templateArgument(request, "ts"), resourceThreadLimit)
+			if err != nil {
+				return nil, describedError("failed to get thread replies", err)
+			}
+			return jsonResource(request.Params.URI, &slack.RenderedThreadReplies{
+				ChannelID: result.ChannelID,
+				ThreadTS:  result.ThreadTS,
+				Messages:  slackClient.RenderMessages(ctx, result.Messages),
+				Truncated: result.Truncated,
+			})
+		}))
+	}
+
+	if granted(userTemplate) {
+		s.AddResourceTemplate(mcp.NewResourceTemplate(userTemplate, "User",
+			mcp.WithTemplateDescription("The profile of a user: ID, or a percent-encoded email, @handle or name (e.g. slack://user/jane%40example.com)"),
+			mcp.WithTemplateMIMEType("application/json"),
+		), read(func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			userID, err := slackClient.ResolveUserID(ctx, templateArgument(request, "id"))
+			if err != nil {
+				return nil, describedError("failed to resolve user", err)
+			}
+			profile, err := slackClient.GetFilteredUserProfile(ctx, userID)
+			if err != nil {
+				return nil, describedError("failed to get user profile", err)
+			}
+			return jsonResource(request.Params.URI, profile)
+		}))
+	}
+}
+
+// templateArgument returns a variable of the resource template the request's URI matched
+func templateArgument(request mcp.ReadResourceRequest, name string) string {
+	switch value := request.Params.Arguments[name].(type) {
+	case string:
+		return value
+	case []string:
+		return strings.Join(value, ",")
+	}
+	return ""
+}
+
+// jsonResource returns v as the JSON contents of the resource uri
+func jsonResource(uri string, v any) ([]mcp.ResourceContents, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize %s: %w", uri, err)
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      uri,
+		MIMEType: "application/json",
+		Text:     string(data),
+	}}, nil
+}
+
+// describedError describes a failed Slack call of a resource or prompt with its error code and hint,
+// which reach the client as the message of the JSON-RPC error
+func describedError(action string, err error) error {
+	details := slack.DescribeError(err)
+	return fmt.Errorf("%s (%s): %s", action, details.Code, details.Message)
+}
+
+// resourceSubscriptions polls the threads and channel histories clients subscribed to and
+// sends notifications/resources/updated to the subscribers when they get new messages.
+// Each resource is polled once however many sessions subscribed to it.
+type resourceSubscriptions struct {
+	workspaces *workspace.Registry
+	interval   time.Duration
+	mu         sync.Mutex
+	watches    map[string]*resourceWatch
+	closed     bool
+}
+
+// resourceWatch is a subscribed resource and the sessions subscribed to it
+type resourceWatch struct {
+	uri         string
+	channelID   string
+	threadTS    string
+	subscribers map[string]transport.Notify
+	stop        context.CancelFunc
+	// wake makes the poller check the resource before its next tick
+	wake chan struct{}
+}
+
+// newResourceSubscriptions returns subscriptions polling the default workspace every interval
+func newResourceSubscriptions(workspaces *workspace.Registry, interval time.Duration) *resourceSubscriptions {
+	return &resourceSubscriptions{
+		workspaces: workspaces,
+		interval:   interval,
+		watches:    make(map[string]*resourceWatch),
+	}
+}
+
+// Subscribe implements transport.Subscriptions. Threads and channel histories can be
+// subscribed to; the resource is read once to check that it exists.
+func (s *resourceSubscriptions) Subscribe(ctx context.Context, sessionID, uri string, notify transport.Notify) error {
+	s.mu.Lock()
+	if w, ok := s.watches[uri]; ok {
+		w.subscribers[sessionID] = notify
+		s.mu.Unlock()
+		return nil
+	}
+	s.mu.Unlock()
+
+	channel, threadTS, err := parseSubscriptionURI(uri)
+	if err != nil {
+		return err
+	}
+	ws, err := s.workspaces.Get("")
+	if err != nil {
+		return err
+	}
+	channelID, err := ws.Client.ResolveChannelID(ctx, channel)
+	if err != nil {
+		return describedError("failed to resolve channel", err)
+	}
+	version, err := resourceVersion(ctx, ws.Client, channelID, threadTS)
+	if err != nil {
+		return describedError("failed to read "+uri, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("the server is shutting down")
+	}
+	w, ok := s.watches[uri]
+	if !ok {
+		pollCtx, stop := context.WithCancel(context.Background())
+		w = &resourceWatch{
+			uri:         uri,
+			channelID:   channelID,
+			threadTS:    threadTS,
+			subscribers: make(map[string]transport.Notify),
+			stop:        stop,
+			wake:        make(chan struct{}, 1),
+		}
+		s.watches[uri] = w
+		go s.poll(pollCtx, w, ws.Client, version)
+	}
+	w.subscribers[sessionID] = notify
+	log.Printf("session %s subscribed to %s", sessionID, uri)
+	return nil
+}
+
+// Unsubscribe implements transport.Subscriptions
+func (s *resourceSubscriptions) Unsubscribe(sessionID, uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w, ok := s.watches[uri]; ok {
+		delete(w.subscribers, sessionID)
+		s.dropIfUnused(w)
+	}
+}
+
+// EndSession implements transport.Subscriptions
+func (s *resourceSubscriptions) EndSession(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, w := range s.watches {
+		delete(w.subscribers, sessionID)
+		s.dropIfUnused(w)
+	}
+}
+
+// Close stops polling every resource
+func (s *resourceSubscriptions) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for _, w := range s.watches {
+		w.stop()
+	}
+	clear(s.watches)
+}
+
+// eventReceived checks the subscribed resources a message event may have changed right away
+// instead of at their next poll, which still decides whether they changed
+func (s *resourceSubscriptions) eventReceived(e events.Event) {
+	if e.Type != "message" {
+		return
+	}
+	if ws, err := s.workspaces.Get(""); err != nil || (e.TeamID != "" && e.TeamID != ws.TeamID) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, w := range s.watches {
+		if w.channelID == e.Channel && (w.threadTS == "" || w.threadTS == e.ThreadTS) {
+			select {
+			case w.wake <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// dropIfUnused stops polling a resource nobody is subscribed to anymore; s.mu must be held
+func (s *resourceSubscriptions) dropIfUnused(w *resourceWatch) {
+	if len(w.subscribers) == 0 {
+		w.stop()
+		delete(s.watches, w.uri)
+	}
+}
+
+// poll checks the resource every interval and notifies the subscribers when it changes
+// from version
+func (s *resourceSubscriptions) poll(ctx context.Context, w *resourceWatch, slackClient *slack.Client, version string) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+
+		// a poll must not outlast the interval, e.g. while the method is rate limited
+		pollCtx, cancel := context.WithTimeout(ctx, s.interval)
+		latest, err := resourceVersion(pollCtx, slackClient, w.channelID, w.threadTS)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("failed to poll %s: %v", w.uri, err)
+			}
+			continue
+		}
+		if latest == version {
+			continue
+		}
+		version = latest
+		s.notify(w)
+	}
+}
+
+// notify sends notifications/resources/updated to the subscribers of a resource, dropping
+// the sessions that no longer receive notifications
+func (s *resourceSubscriptions) notify(w *resourceWatch) {
+	notification := mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: "notifications/resources/updated",
+			Params: mcp.NotificationParams{
+				AdditionalFields: map[string]any{"uri": w.uri},
+			},
+		},
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sessionID, notify := range w.subscribers {
+		if err := notify(notification); err != nil {
+			log.Printf("drop subscription of session %s to %s: %v", sessionID, w.uri, err)
+			delete(w.subscribers, sessionID)
+		}
+	}
+	s.dropIfUnused(w)
+}
+
+// parseSubscriptionURI returns the channel and, for a thread, the thread timestamp a
+// subscribable resource URI names
+func parseSubscriptionURI(uri string) (channel, threadTS string, err error) {
+	if rest, ok := strings.CutPrefix(uri, "slack://thread/"); ok {
+		channel, threadTS, _ = strings.Cut(rest, "/")
+		if channel != "" && threadTS != "" && !strings.Contains(threadTS, "/") {
+			return channel, threadTS, nil
+		}
+	}
+	if rest, ok := strings.CutPrefix(uri, "slack://channel/"); ok {
+		channel, ok = strings.CutSuffix(rest, "/history")
+		if ok && channel != "" && !strings.Contains(channel, "/") {
+			return channel, "", nil
+		}
+	}
+	return "", "", fmt.Errorf("cannot subscribe to %s: only %s and %s resources support subscriptions",
+		uri, threadTemplate, channelHistoryTemplate)
+}
+
+// resourceVersion returns a value that changes when a thread gets a reply, or a channel a
+// new message: the thread's reply count and latest reply, or the channel's newest message
+func resourceVersion(ctx context.Context, slackClient *slack.Client, channelID, threadTS string) (string, error) {
+	if threadTS != "" {
+		// conversations.replies returns the parent first, which carries the thread's state
+		messages, err := slackClient.IterateThreadReplies(channelID, threadTS, 1).Next(ctx)
+		if err != nil || len(messages) == 0 {
+			return "", err
+		}
+		return fmt.Sprintf("%d %s", messages[0].ReplyCount, messages[0].LatestReply), nil
+	}
+
+	history, err := slackClient.GetChannelHistory(ctx, &slack.GetChannelHistoryParameters{
+		ChannelID: channelID,
+		Limit:     1,
+	})
+	if err != nil || len(history.Messages) == 0 {
+		return "", err
+	}
+	return history.Messages[0].Timestamp, nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/shawnzhang/slack-go/pkg/fakeslack"
+	"github.com/shawnzhang/slack-go/pkg/slack"
+	"github.com/shawnzhang/slack-go/pkg/workspace"
+)
+
+// notificationTimeout bounds the wait for a resource update; polls of conversations.replies
+// queue behind its Tier 3 limit once the burst is used up
+const notificationTimeout = 5 * time.Second
+
+// readResource reads a resource and decodes its JSON contents, or returns the JSON-RPC error message
+func readResource(t *testing.T, c *stdioClient, uri string, v any) string {
+	t.Helper()
+	response := c.request("resources/read", map[string]any{"uri": uri})
+	if response.Error != nil {
+		var rpcErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(response.Error, &rpcErr)
+		return rpcErr.Message
+	}
+	var result struct {
+		Contents []struct {
+			URI      string `json:"uri"`
+			MIMEType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"contents"`
+	}
+	if err := json.Unmarshal(response.Result, &result); err != nil || len(result.Contents) != 1 {
+		t.Fatalf("%s: unexpected result %s", uri, response.Result)
+	}
+	if contents := result.Contents[0]; contents.URI != uri || contents.MIMEType != "application/json" {
+		t.Errorf("%s: contents %s of type %s", uri, contents.URI, contents.MIMEType)
+	}
+	if err := json.Unmarshal([]byte(result.Contents[0].Text), v); err != nil {
+		t.Fatalf("%s: failed to decode %s: %v", uri, result.Contents[0].Text, err)
+	}
+	return ""
+}
+
+func TestReadResources(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+	c := startStdio(t, env)
+
+	var channels slack.ChannelListPage
+	if message := readResource(t, c, "slack://channels", &channels); message != "" {
+		t.Fatal(message)
+	}
+	if got := channelNames(channels.Channels); got != "general,random" {
+		t.Errorf("channels = %s, want general,random", got)
+	}
+
+	var history slack.RenderedChannelHistory
+	if message := readResource(t, c, "slack://channel/general/history", &history); message != "" {
+		t.Fatal(message)
+	}
+	if len(history.Messages) != 2 || history.Messages[1].Text != "Hello @bob, see #random & the docs" {
+		t.Errorf("history = %+v", history.Messages)
+	}
+
+	var thread slack.RenderedThreadReplies
+	if message := readResource(t, c, "slack://thread/"+generalID+"/"+env.threadTS, &thread); message != "" {
+		t.Fatal(message)
+	}
+	if len(thread.Messages) != 3 || thread.Messages[2].UserName != "owner" {
+		t.Errorf("thread = %+v", thread.Messages)
+	}
+
+	var user slack.UserProfileInfo
+	// emails and @handles are percent-encoded, as URI template variables cannot hold @
+	if message := readResource(t, c, "slack://user/alice%40example.com", &user); message != "" {
+		t.Fatal(message)
+	}
+	if user.ID != aliceID || user.Title != "Engineer" {
+		t.Errorf("user = %+v", user)
+	}
+
+	if message := readResource(t, c, "slack://channel/"+randomID+"/history", &history); !strings.Contains(message, "(not_in_channel)") {
+		t.Errorf("history of a channel the bot is not in: %q", message)
+	}
+	if message := readResource(t, c, "slack://thread/"+generalID+"/1.000001", &thread); !strings.Contains(message, "(thread_not_found)") {
+		t.Errorf("missing thread: %q", message)
+	}
+}
+
+func TestResourceSubscriptions(t *testing.T) {
+	env := newTestEnv(t, time.Minute)
+	c := startStdio(t, env)
+	c.request("initialize", map[string]any{"protocolVersion": "2024-11-05", "capabilities": map[string]any{}})
+	c.notify("notifications/initialized")
+
+	threadURI := "slack://thread/" + generalID + "/" + env.threadTS
+	historyURI := "slack://channel/general/history"
+	for _, uri := range []string{threadURI, historyURI} {
+		if response := c.request("resources/subscribe", map[string]any{"uri": uri}); response.Error != nil {
+			t.Fatalf("subscribe to %s: %s", uri, response.Error)
+		}
+	}
+
+	// waitUpdate waits for notifications/resources/updated for uri, skipping the others
+	waitUpdate := func(uri string, timeout time.Duration) bool {
+		t.Helper()
+		deadline := time.Now().Add(timeout)
+		for {
+			notification, ok := c.waitNotification("notifications/resources/updated", time.Until(deadline))
+			if !ok {
+				return false
+			}
+			var params struct {
+				URI string `json:"uri"`
+			}
+			json.Unmarshal(notification.Params, &params)
+			if params.URI == uri {
+				return true
+			}
+		}
+	}
+
+	env.fake.AddMessage(generalID, message(aliceID, "rollback done", env.threadTS))
+	if !waitUpdate(threadURI, notificationTimeout) {
+		t.Fatal("no update after a new reply")
+	}
+	env.fake.AddMessage(generalID, message(bobID, "lunch?", ""))
+	if !waitUpdate(historyURI, notificationTimeout) {
+		t.Fatal("no update after a new message")
+	}
+
+	c.request("resources/unsubscribe", map[string]any{"uri": threadURI})
+	env.fake.AddMessage(generalID, message(bobID, "one more thing", env.threadTS))
+	if waitUpdate(threadURI, 10*testPollInterval) {
+		t.Error("update after unsubscribing")
+	}
+
+	for uri, code := range map[string]string{
+		"slack://user/" + aliceID:                  "only slack://thread",
+		"slack://thread/" + generalID + "/1.00001": "thread_not_found",
+		"slack://channel/C0NOWHERE1/history":       "channel_not_found",
+	} {
+		response := c.request("resources/subscribe", map[string]any{"uri": uri})
+		if response.Error == nil || !strings.Contains(string(response.Error), code) {
+			t.Errorf("subscribe to %s: error %s, want %s", uri, response.Error, code)
+		}
+	}
+}
+
+func TestResourcesGatedOnScopes(t *testing.T) {
+	fake := fakeslack.NewServer()
+	defer fake.Close()
+	fake.SetScopes(fakeslack.BotToken, "users:read")
+
+	workspaces, err := workspace.NewRegistry(context.Background(), &workspace.Config{
+		Workspaces: []workspace.WorkspaceConfig{{Alias: "bot", TeamID: fakeslack.TeamID, Token: fakeslack.BotToken, APIURL: fake.URL}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := newServer(workspaces, time.Minute, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var resources struct {
+		Resources []struct {
+			URI string `json:"uri"`
+		} `json:"resources"`
+	}
+	response := s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/list"}`))
+	data, _ := json.Marshal(response.(mcp.JSONRPCResponse).Result)
+	json.Unmarshal(data, &resources)
+	if len(resources.Resources) != 0 {
+		t.Errorf("resources registered without channels:read: %+v", resources.Resources)
+	}
+
+	var templates struct {
+		ResourceTemplates []struct {
+			URITemplate string `json:"uriTemplate"`
+		} `json:"resourceTemplates"`
+	}
+	response = s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":2,"method":"resources/templates/list"}`))
+	data, _ = json.Marshal(response.(mcp.JSONRPCResponse).Result)
+	json.Unmarshal(data, &templates)
+	if len(templates.ResourceTemplates) != 1 || templates.ResourceTemplates[0].URITemplate != userTemplate {
+		t.Errorf("templates = %+v, want only %s", templates.ResourceTemplates, userTemplate)
+	}
+}
@@ -0,0 +1,267 @@
+// Package events buffers the events Slack pushes to the app, so MCP clients can wait for
+// them instead of polling the Web API
+package events
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack/slackevents"
+)
+
+// Types are the event types the buffer keeps; other events are dropped
+var Types = []string{
+	"message", "app_mention", "reaction_added", "reaction_removed",
+	"channel_created", "channel_rename", "channel_archive", "channel_unarchive", "channel_deleted",
+	"member_joined_channel", "member_left_channel",
+}
+
+// Event is the compact form of a Slack event
+type Event struct {
+	// Cursor orders the buffered events; wait with it as After to get the events that follow
+	Cursor int64  `json:"cursor"`
+	ID     string `json:"event_id,omitempty"`
+	Type   string `json:"type"`
+	// SubType is the message subtype, e.g. message_changed or bot_message
+	SubType string `json:"subtype,omitempty"`
+	TeamID  string `json:"team_id,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	User    string `json:"user,omitempty"`
+	Text    string `json:"text,omitempty"`
+	// TS is the message's timestamp, or the reacted message's for reactions
+	TS       string `json:"ts,omitempty"`
+	ThreadTS string `json:"thread_ts,omitempty"`
+	Reaction string `json:"reaction,omitempty"`
+	// Name is the channel's name, set on channel_created and channel_rename
+	Name string `json:"name,omitempty"`
+	// Time is when the event happened, as an RFC 3339 UTC time
+	Time string `json:"time,omitempty"`
+}
+
+// Filter selects buffered events; empty fields match every event
+type Filter struct {
+	// After skips the events up to and including this cursor
+	After    int64
+	TeamID   string
+	Channels []string
+	Types    []string
+	User     string
+	ThreadTS string
+	// Limit caps the events returned; 0 means no cap
+	Limit int
+}
+
+// matches reports whether an event passes the filter
+func (f *Filter) matches(e *Event) bool {
+	return e.Cursor > f.After &&
+		(f.TeamID == "" || e.TeamID == "" || e.TeamID == f.TeamID) &&
+		(len(f.Types) == 0 || slices.Contains(f.Types, e.Type)) &&
+		(f.User == "" || e.User == f.User) &&
+		(f.ThreadTS == "" || e.ThreadTS == f.ThreadTS || e.TS == f.ThreadTS)
+}
+
+// seenSize is how many recent event IDs the buffer remembers to drop redelivered events
+const seenSize = 1000
+
+// Buffer holds the latest events of each channel in a bounded ring, so a busy channel
+// cannot push out the events of quiet ones
+type Buffer struct {
+	size      int
+	mu        sync.Mutex
+	cursor    int64
+	channels  map[string]*ring
+	changed   chan struct{}
+	listeners []func(Event)
+	// seen holds the IDs in seenIDs, the latest seenSize event IDs added
+	seen    map[string]bool
+	seenIDs []string
+}
+
+// ring is the latest events of one channel, oldest first
+type ring struct {
+	events []Event
+	next   int
+}
+
+// NewBuffer returns a buffer keeping up to size events per channel
+func NewBuffer(size int) *Buffer {
+	return &Buffer{
+		size:     max(size, 1),
+		channels: make(map[string]*ring),
+		changed:  make(chan struct{}),
+		seen:     make(map[string]bool),
+	}
+}
+
+// Listen calls listener with every event added from now on. Listeners run on the adding
+// goroutine and must not block.
+func (b *Buffer) Listen(listener func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, listener)
+}
+
+// Add assigns the event the next cursor, stores it in its channel's ring and wakes the
+// waiters, returning the stored event. An event whose ID was added recently, which Slack
+// redelivers when it missed the acknowledgement, is dropped and reported false.
+func (b *Buffer) Add(e Event) (Event, bool) {
+	b.mu.Lock()
+	if e.ID != "" {
+		if b.seen[e.ID] {
+			b.mu.Unlock()
+			return Event{}, false
+		}
+		if len(b.seenIDs) == seenSize {
+			delete(b.seen, b.seenIDs[0])
+			b.seenIDs = b.seenIDs[1:]
+		}
+		b.seen[e.ID] = true
+		b.seenIDs = append(b.seenIDs, e.ID)
+	}
+	b.cursor++
+	e.Cursor = b.cursor
+	r, ok := b.channels[e.Channel]
+	if !ok {
+		r = &ring{}
+		b.channels[e.Channel] = r
+	}
+	if len(r.events) < b.size {
+		r.events = append(r.events, e)
+	} else {
+		r.events[r.next] = e
+		r.next = (r.next + 1) % b.size
+	}
+	close(b.changed)
+	b.changed = make(chan struct{})
+	listeners := b.listeners
+	b.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(e)
+	}
+	return e, true
+}
+
+// Cursor returns the cursor of the latest event added
+func (b *Buffer) Cursor() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cursor
+}
+
+// Wait returns the buffered events matching filter, oldest first, waiting for one to arrive
+// until ctx ends when there are none. The returned cursor is the one to wait after next: the
+// last event's when Limit cut the events short, otherwise the latest event's in the buffer.
+func (b *Buffer) Wait(ctx context.Context, filter Filter) ([]Event, int64) {
+	for {
+		b.mu.Lock()
+		events, cursor := b.find(&filter)
+		changed := b.changed
+		b.mu.Unlock()
+		if len(events) > 0 {
+			return events, cursor
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, max(cursor, filter.After)
+		case <-changed:
+		}
+	}
+}
+
+// find collects the events matching filter; b.mu must be held
+func (b *Buffer) find(filter *Filter) ([]Event, int64) {
+	var events []Event
+	collect := func(r *ring) {
+		for i := range r.events {
+			if e := &r.events[(r.next+i)%len(r.events)]; filter.matches(e) {
+				events = append(events, *e)
+			}
+		}
+	}
+	if len(filter.Channels) > 0 {
+		for _, channel := range filter.Channels {
+			if r, ok := b.channels[channel]; ok {
+				collect(r)
+			}
+		}
+	} else {
+		for _, r := range b.channels {
+			collect(r)
+		}
+	}
+
+	slices.SortFunc(events, func(x, y Event) int { return cmp.Compare(x.Cursor, y.Cursor) })
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		events = events[:filter.Limit]
+		return events, events[len(events)-1].Cursor
+	}
+	return events, b.cursor
+}
+
+// FromEventsAPI converts the inner event of an Events API callback, reporting false for
+// event types the buffer does not keep
+func FromEventsAPI(outer slackevents.EventsAPIEvent) (Event, bool) {
+	e := Event{TeamID: outer.TeamID, Type: outer.InnerEvent.Type}
+	if callback, ok := outer.Data.(*slackevents.EventsAPICallbackEvent); ok {
+		e.ID = callback.EventID
+	}
+
+	var eventTS string
+	switch inner := outer.InnerEvent.Data.(type) {
+	case *slackevents.MessageEvent:
+		e.SubType, e.Channel, eventTS = inner.SubType, inner.Channel, inner.EventTimeStamp
+		msg := inner
+		if inner.Message != nil {
+			// edits carry the new version of the message
+			msg = inner.Message
+		}
+		e.User, e.Text, e.TS, e.ThreadTS = msg.User, msg.Text, msg.TimeStamp, msg.ThreadTimeStamp
+		if inner.SubType == "message_deleted" {
+			e.TS = inner.DeletedTimeStamp
+		}
+	case *slackevents.AppMentionEvent:
+		e.Channel, e.User, e.Text, e.TS, e.ThreadTS, eventTS = inner.Channel, inner.User, inner.Text, inner.TimeStamp, inner.ThreadTimeStamp, inner.EventTimeStamp
+	case *slackevents.ReactionAddedEvent:
+		e.Channel, e.User, e.Reaction, e.TS, eventTS = inner.Item.Channel, inner.User, inner.Reaction, inner.Item.Timestamp, inner.EventTimestamp
+	case *slackevents.ReactionRemovedEvent:
+		e.Channel, e.User, e.Reaction, e.TS, eventTS = inner.Item.Channel, inner.User, inner.Reaction, inner.Item.Timestamp, inner.EventTimestamp
+	case *slackevents.ChannelCreatedEvent:
+		e.Channel, e.User, e.Name, eventTS = inner.Channel.ID, inner.Channel.Creator, inner.Channel.Name, inner.EventTimestamp
+	case *slackevents.ChannelRenameEvent:
+		e.Channel, e.Name, eventTS = inner.Channel.ID, inner.Channel.Name, inner.EventTimestamp
+	case *slackevents.ChannelArchiveEvent:
+		e.Channel, e.User, eventTS = inner.Channel, inner.User, inner.EventTimestamp
+	case *slackevents.ChannelUnarchiveEvent:
+		e.Channel, e.User, eventTS = inner.Channel, inner.User, inner.EventTimestamp
+	case *slackevents.ChannelDeletedEvent:
+		e.Channel, eventTS = inner.Channel, inner.EventTimestamp
+	case *slackevents.MemberJoinedChannelEvent:
+		e.Channel, e.User, eventTS = inner.Channel, inner.User, inner.EventTimestamp
+	case *slackevents.MemberLeftChannelEvent:
+		e.Channel, e.User, eventTS = inner.Channel, inner.User, inner.EventTimestamp
+	default:
+		return Event{}, false
+	}
+	if !slices.Contains(Types, e.Type) {
+		return Event{}, false
+	}
+	e.Time = timestampTime(eventTS)
+	return e, true
+}
+
+// timestampTime formats a Slack timestamp as an RFC 3339 UTC time, or "" when it does not parse
+func timestampTime(ts string) string {
+	seconds, _, _ := strings.Cut(ts, ".")
+	unix, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return ""
+	}
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack/slackevents"
+)
+
+// timestamps lists the TS of events, e.g. "1,2,3"
+func timestamps(events []Event) string {
+	var list []string
+	for _, e := range events {
+		list = append(list, e.TS)
+	}
+	return strings.Join(list, ",")
+}
+
+func TestBuffer(t *testing.T) {
+	b := NewBuffer(2)
+	for _, e := range []Event{
+		{Type: "message", Channel: "C1", TS: "1"},
+		{Type: "message", Channel: "C1", TS: "2"},
+		{Type: "message", Channel: "C2", TS: "3"},
+		{Type: "message", Channel: "C1", TS: "4"},
+	} {
+		b.Add(e)
+	}
+	done, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// the busy channel drops its oldest event, the quiet one keeps its only event
+	if events, cursor := b.Wait(done, Filter{}); timestamps(events) != "2,3,4" || cursor != 4 {
+		t.Errorf("events = %s, cursor %d; want 2,3,4 and 4", timestamps(events), cursor)
+	}
+	if events, cursor := b.Wait(done, Filter{Limit: 2}); timestamps(events) != "2,3" || cursor != 3 {
+		t.Errorf("limited events = %s, cursor %d; want 2,3 and 3", timestamps(events), cursor)
+	}
+	if events, _ := b.Wait(done, Filter{Channels: []string{"C2"}, After: 1}); timestamps(events) != "3" {
+		t.Errorf("events of C2 = %s, want 3", timestamps(events))
+	}
+
+	// a wait with nothing to return blocks until a matching event arrives
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		b.Add(Event{Type: "message", Channel: "C2", TS: "5", User: "U2"})
+		b.Add(Event{Type: "reaction_added", Channel: "C2", TS: "6", User: "U1"})
+	}()
+	if events, cursor := b.Wait(ctx, Filter{After: 4, User: "U1"}); timestamps(events) != "6" || cursor != 6 {
+		t.Errorf("waited for %s, cursor %d; want 6 and 6", timestamps(events), cursor)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if events, cursor := b.Wait(ctx, Filter{After: 6}); len(events) != 0 || cursor != 6 {
+		t.Errorf("wait timed out with %s, cursor %d; want nothing and 6", timestamps(events), cursor)
+	}
+}
+
+func TestFromEventsAPI(t *testing.T) {
+	convert := func(event string) (Event, bool) {
+		t.Helper()
+		outer, err := slackevents.ParseEvent(json.RawMessage(
+			`{"type":"event_callback","team_id":"T1","event_id":"Ev1","event":`+event+`}`), slackevents.OptionNoVerifyToken())
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", event, err)
+		}
+		return FromEventsAPI(outer)
+	}
+
+	for event, want := range map[string]Event{
+		`{"type":"message","subtype":"message_changed","channel":"C1","event_ts":"1740819660.000200",
+		  "message":{"type":"message","user":"U1","text":"fixed","ts":"1740819600.000100","thread_ts":"1740819000.000100"}}`: {
+			ID: "Ev1", Type: "message", SubType: "message_changed", TeamID: "T1", Channel: "C1", User: "U1",
+			Text: "fixed", TS: "1740819600.000100", ThreadTS: "1740819000.000100", Time: "2025-03-01T09:01:00Z",
+		},
+		`{"type":"reaction_removed","user":"U2","reaction":"eyes","item_user":"U1",
+		  "item":{"type":"message","channel":"C1","ts":"1740819600.000100"},"event_ts":"1740819660.000200"}`: {
+			ID: "Ev1", Type: "reaction_removed", TeamID: "T1", Channel: "C1", User: "U2", Reaction: "eyes",
+			TS: "1740819600.000100", Time: "2025-03-01T09:01:00Z",
+		},
+		`{"type":"channel_rename","channel":{"id":"C1","name":"incidents","created":1740819600},"event_ts":"1740819660.000200"}`: {
+			ID: "Ev1", Type: "channel_rename", TeamID: "T1", Channel: "C1", Name: "incidents", Time: "2025-03-01T09:01:00Z",
+		},
+	} {
+		if got, ok := convert(event); !ok || got != want {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", event, got, want)
+		}
+	}
+
+	if e, ok := convert(`{"type":"team_join","user":{"id":"U3"},"event_ts":"1740819660.000200"}`); ok {
+		t.Errorf("team_join was kept: %+v", e)
+	}
+}
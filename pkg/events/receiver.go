@@ -0,0 +1,117 @@
+package events
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/slack-go/slack/slackevents"
+)
+
+// replayWindow is how far a request's X-Slack-Request-Timestamp may be from now; older
+// requests are rejected so a captured request cannot be replayed
+const replayWindow = 5 * time.Minute
+
+// maxBodySize caps the request bodies the receiver reads
+const maxBodySize = 1 << 20
+
+// Receiver is the Request URL of the Events API, for deployments that cannot use Socket
+// Mode. It verifies each request's signature, answers the url_verification challenge and
+// adds the events of event_callback requests to a Buffer.
+type Receiver struct {
+	signingSecret string
+	buffer        *Buffer
+	now           func() time.Time
+}
+
+// NewReceiver returns a receiver verifying requests with the app's signing secret
+func NewReceiver(signingSecret string, buffer *Buffer) *Receiver {
+	return &Receiver{signingSecret: signingSecret, buffer: buffer, now: time.Now}
+}
+
+// Sign returns the X-Slack-Signature of a request body sent at timestamp (Unix seconds)
+func Sign(signingSecret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	fmt.Fprintf(mac, "v0:%d:", timestamp)
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks the request's signature and timestamp
+func (r *Receiver) verify(header http.Header, body []byte) error {
+	timestamp, err := strconv.ParseInt(header.Get("X-Slack-Request-Timestamp"), 10, 64)
+	if err != nil {
+		return errors.New("missing or invalid X-Slack-Request-Timestamp")
+	}
+	if age := r.now().Sub(time.Unix(timestamp, 0)); age > replayWindow || age < -replayWindow {
+		return fmt.Errorf("request timestamp is %s away, outside the %s window", age.Round(time.Second), replayWindow)
+	}
+	if !hmac.Equal([]byte(header.Get("X-Slack-Signature")), []byte(Sign(r.signingSecret, timestamp, body))) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// ServeHTTP handles an Events API request. Slack retries requests not answered with 200
+// within 3 seconds, so events are buffered before answering and redeliveries, marked with
+// X-Slack-Retry-Num, are acknowledged without being buffered twice.
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err := r.verify(req.Header, body); err != nil {
+		log.Printf("events api: rejected request from %s: %v", req.RemoteAddr, err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var envelope struct {
+		Type      string `json:"type"`
+		Challenge string `json:"challenge"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		log.Printf("events api: failed to parse request: %v", err)
+		// a payload that does not parse will not parse on redelivery either
+		w.Header().Set("X-Slack-No-Retry", "1")
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	switch envelope.Type {
+	case slackevents.URLVerification:
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, envelope.Challenge)
+	case slackevents.CallbackEvent:
+		if retry := req.Header.Get("X-Slack-Retry-Num"); retry != "" {
+			log.Printf("events api: redelivery %s of an event (%s)", retry, req.Header.Get("X-Slack-Retry-Reason"))
+		}
+		// event types slackevents does not know fail to parse; they are acknowledged all the
+		// same so Slack does not redeliver them
+		if outer, err := slackevents.ParseEvent(json.RawMessage(body), slackevents.OptionNoVerifyToken()); err != nil {
+			log.Printf("events api: ignored event: %v", err)
+		} else if e, ok := FromEventsAPI(outer); ok {
+			if _, added := r.buffer.Add(e); !added {
+				log.Printf("events api: dropped duplicate event %s", e.ID)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		// app_rate_limited and other notices need no answer beyond the acknowledgement
+		log.Printf("events api: ignored %s request", envelope.Type)
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReceiver(t *testing.T) {
+	now := time.Unix(1740819600, 0)
+	buffer := NewBuffer(10)
+	r := NewReceiver("secret", buffer)
+	r.now = func() time.Time { return now }
+
+	post := func(body string, sentAt time.Time, signature string, header http.Header) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+		req.Header.Set("X-Slack-Request-Timestamp", strconv.FormatInt(sentAt.Unix(), 10))
+		if signature == "" {
+			signature = Sign("secret", sentAt.Unix(), []byte(body))
+		}
+		req.Header.Set("X-Slack-Signature", signature)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post(`{"type":"url_verification","challenge":"c-123","token":"t"}`, now, "", nil); w.Code != http.StatusOK || w.Body.String() != "c-123" {
+		t.Errorf("challenge answered %d %q, want 200 c-123", w.Code, w.Body.String())
+	}
+
+	message := `{"type":"event_callback","team_id":"T1","event_id":"Ev1","event":` +
+		`{"type":"message","channel":"C1","user":"U1","text":"hi","ts":"1740819660.000100","event_ts":"1740819660.000100"}}`
+	for name, w := range map[string]*httptest.ResponseRecorder{
+		"wrong secret":    post(message, now, Sign("other", now.Unix(), []byte(message)), nil),
+		"stale timestamp": post(message, now.Add(-6*time.Minute), "", nil),
+		"future":          post(message, now.Add(6*time.Minute), "", nil),
+	} {
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: answered %d, want 401", name, w.Code)
+		}
+	}
+	if cursor := buffer.Cursor(); cursor != 0 {
+		t.Fatalf("rejected requests were buffered, cursor %d", cursor)
+	}
+
+	if w := post(message, now.Add(-4*time.Minute), "", nil); w.Code != http.StatusOK {
+		t.Errorf("event answered %d, want 200", w.Code)
+	}
+	// Slack redelivers an event it thinks was missed; it is acknowledged but not buffered again
+	retry := http.Header{"X-Slack-Retry-Num": {"1"}, "X-Slack-Retry-Reason": {"http_timeout"}}
+	if w := post(message, now, "", retry); w.Code != http.StatusOK {
+		t.Errorf("redelivery answered %d, want 200", w.Code)
+	}
+	// event types that are not buffered are acknowledged too
+	if w := post(`{"type":"event_callback","team_id":"T1","event_id":"Ev2","event":{"type":"pin_added","user":"U1"}}`, now, "", nil); w.Code != http.StatusOK {
+		t.Errorf("unknown event answered %d, want 200", w.Code)
+	}
+	done, cancel := context.WithCancel(context.Background())
+	cancel()
+	if events, cursor := buffer.Wait(done, Filter{}); len(events) != 1 || events[0].ID != "Ev1" || events[0].Text != "hi" || cursor != 1 {
+		t.Errorf("buffered %+v, cursor %d; want Ev1 once", events, cursor)
+	}
+
+	if w := post(`{"type":`, now, "", nil); w.Code != http.StatusBadRequest || w.Header().Get("X-Slack-No-Retry") != "1" {
+		t.Errorf("invalid payload answered %d, X-Slack-No-Retry %q; want 400 and 1", w.Code, w.Header().Get("X-Slack-No-Retry"))
+	}
+}
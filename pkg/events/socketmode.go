@@ -0,0 +1,84 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// SocketMode receives the app's events over a Socket Mode connection, which needs no public
+// endpoint, and adds them to a Buffer
+type SocketMode struct {
+	client *socketmode.Client
+	buffer *Buffer
+}
+
+// NewSocketMode returns a listener connecting with an app-level token (xapp-...) that has the
+// connections:write scope. apiURL is the Web API base URL; https://slack.com/api/ when empty.
+func NewSocketMode(appToken, apiURL string, buffer *Buffer) *SocketMode {
+	options := []slack.Option{slack.OptionAppLevelToken(appToken)}
+	if apiURL != "" {
+		options = append(options, slack.OptionAPIURL(apiURL))
+	}
+	return &SocketMode{
+		client: socketmode.New(slack.New("", options...)),
+		buffer: buffer,
+	}
+}
+
+// Run keeps the connection open, reconnecting when Slack closes it, until ctx ends or the
+// token is rejected
+func (s *SocketMode) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.client.RunContext(ctx)
+	}()
+
+	for {
+		select {
+		case err := <-done:
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		case evt := <-s.client.Events:
+			s.handle(evt)
+		}
+	}
+}
+
+// handle acknowledges an Events API envelope, so Slack does not redeliver it, and buffers
+// its event
+func (s *SocketMode) handle(evt socketmode.Event) {
+	switch evt.Type {
+	case socketmode.EventTypeConnecting:
+		log.Printf("socket mode: connecting")
+	case socketmode.EventTypeConnected:
+		log.Printf("socket mode: connected")
+	case socketmode.EventTypeConnectionError:
+		if connErr, ok := evt.Data.(*slack.ConnectionErrorEvent); ok {
+			log.Printf("socket mode: connection attempt %d failed, retrying in %s: %v", connErr.Attempt, connErr.Backoff, connErr.ErrorObj)
+		}
+	case socketmode.EventTypeInvalidAuth:
+		log.Printf("socket mode: the app token was rejected")
+	case socketmode.EventTypeDisconnect:
+		log.Printf("socket mode: Slack asked to reconnect")
+	case socketmode.EventTypeEventsAPI:
+		if evt.Request != nil {
+			s.client.Ack(*evt.Request)
+		}
+		outer, ok := evt.Data.(slackevents.EventsAPIEvent)
+		if !ok {
+			return
+		}
+		if e, ok := FromEventsAPI(outer); ok {
+			s.buffer.Add(e)
+		}
+	}
+}
@@ -0,0 +1,525 @@
+package fakeslack
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// authTest answers auth.test with the token's user and team
+func (s *Server) authTest(r *request) (response, *slackError) {
+	user := s.user(r.token.userID)
+	result := response{
+		"url":     "https://" + TeamDomain + ".slack.com/",
+		"team":    TeamName,
+		"user":    user.Name,
+		"team_id": TeamID,
+		"user_id": user.ID,
+	}
+	if r.token.botID != "" {
+		result["bot_id"] = r.token.botID
+	}
+	if s.enterpriseID != "" {
+		result["enterprise_id"] = s.enterpriseID
+	}
+	return result, nil
+}
+
+// conversationType names the type of a conversation as conversations.list filters it
+func conversationType(c *conversation) string {
+	switch {
+	case c.IsIM:
+		return "im"
+	case c.IsMpIM:
+		return "mpim"
+	case c.IsPrivate:
+		return "private_channel"
+	default:
+		return "public_channel"
+	}
+}
+
+// conversationsList lists the conversations of the requested types the token can see
+func (s *Server) conversationsList(r *request) (response, *slackError) {
+	types := strings.Split(r.params.Get("types"), ",")
+	if r.params.Get("types") == "" {
+		types = []string{"public_channel"}
+	}
+	excludeArchived, _ := strconv.ParseBool(r.params.Get("exclude_archived"))
+
+	var channels []slack.Channel
+	for _, c := range s.channels {
+		if !slices.Contains(types, conversationType(c)) || (excludeArchived && c.IsArchived) {
+			continue
+		}
+		if _, err := s.visibleChannel(r, c.ID); err == nil {
+			channels = append(channels, c.channelJSON())
+		}
+	}
+
+	start, end, nextCursor, err := page(r.params, len(channels), 100)
+	if err != nil {
+		return nil, err
+	}
+	return response{
+		"channels":          channels[start:end],
+		"response_metadata": response{"next_cursor": nextCursor},
+	}, nil
+}
+
+// conversationsInfo describes one conversation
+func (s *Server) conversationsInfo(r *request) (response, *slackError) {
+	c, err := s.visibleChannel(r, r.params.Get("channel"))
+	if err != nil {
+		return nil, err
+	}
+	return response{"channel": c.channelJSON()}, nil
+}
+
+// conversationsHistory lists the messages posted to the channel itself, newest first:
+// thread parents and broadcast replies, but not the other replies
+func (s *Server) conversationsHistory(r *request) (response, *slackError) {
+	c, err := s.readableChannel(r, r.params.Get("channel"))
+	if err != nil {
+		return nil, err
+	}
+	inclusive, _ := strconv.ParseBool(r.params.Get("inclusive"))
+	oldest := parseTimestamp(r.params.Get("oldest"))
+	latest := parseTimestamp(r.params.Get("latest"))
+
+	var messages []slack.Message
+	for i := len(c.messages) - 1; i >= 0; i-- {
+		msg := c.messages[i]
+		if isReply(msg) && msg.SubType != "thread_broadcast" {
+			continue
+		}
+		t := parseTimestamp(msg.Timestamp)
+		if r.params.Get("oldest") != "" && (t.Before(oldest) || (!inclusive && t.Equal(oldest))) {
+			continue
+		}
+		if r.params.Get("latest") != "" && (t.After(latest) || (!inclusive && t.Equal(latest))) {
+			continue
+		}
+		messages = append(messages, *msg)
+	}
+
+	start, end, nextCursor, err := page(r.params, len(messages), 100)
+	if err != nil {
+		return nil, err
+	}
+	return response{
+		"messages":          messages[start:end],
+		"has_more":          nextCursor != "",
+		"response_metadata": response{"next_cursor": nextCursor},
+	}, nil
+}
+
+// conversationsReplies lists a thread, parent first. The timestamp of a reply selects its thread.
+func (s *Server) conversationsReplies(r *request) (response, *slackError) {
+	c, err := s.readableChannel(r, r.params.Get("channel"))
+	if err != nil {
+		return nil, err
+	}
+	msg := c.message(r.params.Get("ts"))
+	if msg == nil {
+		return nil, fail("thread_not_found")
+	}
+	threadTS := msg.Timestamp
+	if isReply(msg) {
+		threadTS = msg.ThreadTimestamp
+	}
+
+	var messages []slack.Message
+	for _, m := range c.messages {
+		if m.Timestamp == threadTS || m.ThreadTimestamp == threadTS {
+			messages = append(messages, *m)
+		}
+	}
+
+	start, end, nextCursor, err := page(r.params, len(messages), 100)
+	if err != nil {
+		return nil, err
+	}
+	return response{
+		"messages":          messages[start:end],
+		"has_more":          nextCursor != "",
+		"response_metadata": response{"next_cursor": nextCursor},
+	}, nil
+}
+
+// postMessage posts a message as the token's user, into a thread when thread_ts is given
+func (s *Server) postMessage(r *request) (response, *slackError) {
+	c, msg, err := s.newMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	s.addMessage(c, msg)
+	return response{"channel": c.ID, "ts": msg.Timestamp, "message": msg}, nil
+}
+
+// newMessage builds the message a chat.postMessage or chat.scheduleMessage call posts as
+// the token's user, checking the token may post to the channel and the thread exists
+func (s *Server) newMessage(r *request) (*conversation, *slack.Message, *slackError) {
+	c, err := s.visibleChannel(r, r.params.Get("channel"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.IsArchived {
+		return nil, nil, fail("is_archived")
+	}
+	if !c.members[r.token.userID] {
+		return nil, nil, fail("not_in_channel")
+	}
+
+	msg := &slack.Message{}
+	msg.User = r.token.userID
+	msg.BotID = r.token.botID
+	msg.Text = r.params.Get("text")
+	msg.Username = r.params.Get("username")
+	if blocks := r.params.Get("blocks"); blocks != "" {
+		if err := json.Unmarshal([]byte(blocks), &msg.Blocks); err != nil {
+			return nil, nil, fail("invalid_blocks")
+		}
+	}
+	if attachments := r.params.Get("attachments"); attachments != "" {
+		if err := json.Unmarshal([]byte(attachments), &msg.Attachments); err != nil {
+			return nil, nil, fail("invalid_attachments")
+		}
+	}
+	if msg.Text == "" && len(msg.Blocks.BlockSet) == 0 && len(msg.Attachments) == 0 {
+		return nil, nil, fail("no_text")
+	}
+	if threadTS := r.params.Get("thread_ts"); threadTS != "" {
+		parent := c.message(threadTS)
+		if parent == nil || isReply(parent) {
+			return nil, nil, fail("thread_not_found")
+		}
+		msg.ThreadTimestamp = threadTS
+		if broadcast, _ := strconv.ParseBool(r.params.Get("reply_broadcast")); broadcast {
+			msg.SubType = "thread_broadcast"
+		}
+	}
+	return c, msg, nil
+}
+
+// maxScheduleAhead is how far ahead chat.scheduleMessage accepts post_at
+const maxScheduleAhead = 120 * 24 * time.Hour
+
+// scheduleMessage queues a message to be posted at post_at. The fake checks post_at against
+// the real clock like Slack does, but never posts scheduled messages.
+func (s *Server) scheduleMessage(r *request) (response, *slackError) {
+	postAt, convErr := strconv.ParseInt(r.params.Get("post_at"), 10, 64)
+	if convErr != nil {
+		return nil, fail("invalid_time")
+	}
+	now := time.Now()
+	switch at := time.Unix(postAt, 0); {
+	case !at.After(now):
+		return nil, fail("time_in_past")
+	case at.After(now.Add(maxScheduleAhead)):
+		return nil, fail("time_too_far")
+	}
+	c, msg, err := s.newMessage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s.scheduledCount++
+	scheduled := &scheduledMessage{
+		id:          fmt.Sprintf("Q0FAKE%04d", s.scheduledCount),
+		channel:     c.ID,
+		postAt:      postAt,
+		dateCreated: now.Unix(),
+		msg:         msg,
+	}
+	s.scheduled = append(s.scheduled, scheduled)
+	return response{
+		"channel":              c.ID,
+		"scheduled_message_id": scheduled.id,
+		"post_at":              strconv.FormatInt(postAt, 10),
+		"message":              msg,
+	}, nil
+}
+
+// scheduledMessagesList lists the token's scheduled messages by post time, in one channel
+// when channel is given
+func (s *Server) scheduledMessagesList(r *request) (response, *slackError) {
+	channelID := r.params.Get("channel")
+	var messages []response
+	for _, scheduled := range s.scheduled {
+		if scheduled.msg.User != r.token.userID || (channelID != "" && scheduled.channel != channelID) {
+			continue
+		}
+		messages = append(messages, response{
+			"id":           scheduled.id,
+			"channel_id":   scheduled.channel,
+			"post_at":      scheduled.postAt,
+			"date_created": scheduled.dateCreated,
+			"text":         scheduled.msg.Text,
+		})
+	}
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i]["post_at"].(int64) < messages[j]["post_at"].(int64)
+	})
+
+	start, end, nextCursor, err := page(r.params, len(messages), 100)
+	if err != nil {
+		return nil, err
+	}
+	return response{
+		"scheduled_messages": append([]response{}, messages[start:end]...),
+		"response_metadata":  response{"next_cursor": nextCursor},
+	}, nil
+}
+
+// deleteScheduledMessage removes one of the token's scheduled messages
+func (s *Server) deleteScheduledMessage(r *request) (response, *slackError) {
+	for i, scheduled := range s.scheduled {
+		if scheduled.id == r.params.Get("scheduled_message_id") && scheduled.channel == r.params.Get("channel") &&
+			scheduled.msg.User == r.token.userID {
+			s.scheduled = slices.Delete(s.scheduled, i, i+1)
+			return response{}, nil
+		}
+	}
+	return nil, fail("invalid_scheduled_message_id")
+}
+
+// getPermalink links to a message
+func (s *Server) getPermalink(r *request) (response, *slackError) {
+	c, err := s.visibleChannel(r, r.params.Get("channel"))
+	if err != nil {
+		return nil, err
+	}
+	msg := c.message(r.params.Get("message_ts"))
+	if msg == nil {
+		return nil, fail("message_not_found")
+	}
+	return response{"channel": c.ID, "permalink": permalink(c.ID, msg)}, nil
+}
+
+// reactedMessage returns the message a reactions call refers to
+func (s *Server) reactedMessage(r *request) (*conversation, *slack.Message, *slackError) {
+	c, err := s.readableChannel(r, r.params.Get("channel"))
+	if err != nil {
+		return nil, nil, err
+	}
+	msg := c.message(r.params.Get("timestamp"))
+	if msg == nil {
+		return nil, nil, fail("message_not_found")
+	}
+	return c, msg, nil
+}
+
+// emojiNamePattern matches the emoji names reactions.add accepts, with an optional skin tone
+var emojiNamePattern = regexp.MustCompile(`^[a-z0-9_+\-']+(::skin-tone-[2-6])?$`)
+
+// reactionsAdd adds the token's user to a reaction of a message
+func (s *Server) reactionsAdd(r *request) (response, *slackError) {
+	_, msg, err := s.reactedMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	name := r.params.Get("name")
+	if !emojiNamePattern.MatchString(name) {
+		return nil, fail("invalid_name")
+	}
+
+	i := slices.IndexFunc(msg.Reactions, func(reaction slack.ItemReaction) bool { return reaction.Name == name })
+	if i < 0 {
+		msg.Reactions = append(msg.Reactions, slack.ItemReaction{Name: name})
+		i = len(msg.Reactions) - 1
+	}
+	if slices.Contains(msg.Reactions[i].Users, r.token.userID) {
+		return nil, fail("already_reacted")
+	}
+	msg.Reactions[i].Users = append(msg.Reactions[i].Users, r.token.userID)
+	msg.Reactions[i].Count++
+	return response{}, nil
+}
+
+// reactionsRemove removes the token's user from a reaction of a message
+func (s *Server) reactionsRemove(r *request) (response, *slackError) {
+	_, msg, err := s.reactedMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	name := r.params.Get("name")
+	i := slices.IndexFunc(msg.Reactions, func(reaction slack.ItemReaction) bool { return reaction.Name == name })
+	if i < 0 || !slices.Contains(msg.Reactions[i].Users, r.token.userID) {
+		return nil, fail("no_reaction")
+	}
+
+	reaction := &msg.Reactions[i]
+	reaction.Users = slices.DeleteFunc(reaction.Users, func(userID string) bool { return userID == r.token.userID })
+	reaction.Count--
+	if reaction.Count == 0 {
+		msg.Reactions = slices.Delete(msg.Reactions, i, i+1)
+	}
+	return response{}, nil
+}
+
+// reactionsGet returns a message with its reactions
+func (s *Server) reactionsGet(r *request) (response, *slackError) {
+	c, msg, err := s.reactedMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	return response{"type": "message", "channel": c.ID, "message": msg}, nil
+}
+
+// searchMessages finds messages in the conversations the user can see. The query is a
+// list of words that must all appear in the text, plus in:#channel and from:@user modifiers.
+func (s *Server) searchMessages(r *request) (response, *slackError) {
+	if r.token.botID != "" {
+		return nil, fail("not_allowed_token_type")
+	}
+	query := r.params.Get("query")
+	if strings.TrimSpace(query) == "" {
+		return nil, fail("no_query")
+	}
+
+	var words []string
+	var inChannel, fromUser string
+	for _, term := range strings.Fields(query) {
+		switch {
+		case strings.HasPrefix(term, "in:"):
+			inChannel = strings.Trim(strings.TrimPrefix(term, "in:"), "#<>")
+		case strings.HasPrefix(term, "from:"):
+			fromUser = strings.Trim(strings.TrimPrefix(term, "from:"), "@<>")
+		default:
+			words = append(words, strings.ToLower(term))
+		}
+	}
+
+	var matches []slack.SearchMessage
+	for _, c := range s.channels {
+		if _, err := s.visibleChannel(r, c.ID); err != nil {
+			continue
+		}
+		if inChannel != "" && c.ID != inChannel && c.Name != inChannel {
+			continue
+		}
+		for _, msg := range c.messages {
+			author := s.user(msg.User)
+			if fromUser != "" && (author == nil || (author.ID != fromUser && author.Name != fromUser)) {
+				continue
+			}
+			text := strings.ToLower(msg.Text)
+			if !allContained(text, words) {
+				continue
+			}
+			match := slack.SearchMessage{
+				Type:      "message",
+				Channel:   slack.CtxChannel{ID: c.ID, Name: c.Name},
+				User:      msg.User,
+				Timestamp: msg.Timestamp,
+				Text:      msg.Text,
+				Permalink: permalink(c.ID, msg),
+			}
+			if author != nil {
+				match.Username = author.Name
+			}
+			matches = append(matches, match)
+		}
+	}
+	ascending := r.params.Get("sort_dir") == "asc"
+	sort.SliceStable(matches, func(i, j int) bool {
+		before := parseTimestamp(matches[i].Timestamp).Before(parseTimestamp(matches[j].Timestamp))
+		return before == ascending
+	})
+
+	count, _ := strconv.Atoi(r.params.Get("count"))
+	if count <= 0 {
+		count = 20
+	}
+	pageNumber, _ := strconv.Atoi(r.params.Get("page"))
+	if pageNumber <= 0 {
+		pageNumber = 1
+	}
+	pages := (len(matches) + count - 1) / count
+	start := min((pageNumber-1)*count, len(matches))
+	end := min(start+count, len(matches))
+
+	return response{
+		"query": query,
+		"messages": slack.SearchMessages{
+			Matches: matches[start:end],
+			Paging:  slack.Paging{Count: count, Total: len(matches), Page: pageNumber, Pages: pages},
+			Pagination: slack.Pagination{
+				TotalCount: len(matches),
+				Page:       pageNumber,
+				PerPage:    count,
+				PageCount:  pages,
+				First:      start + 1,
+				Last:       end,
+			},
+			Total: len(matches),
+		},
+	}, nil
+}
+
+// allContained reports whether every word appears in text
+func allContained(text string, words []string) bool {
+	for _, word := range words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// usergroupsList lists the workspace's user groups
+func (s *Server) usergroupsList(r *request) (response, *slackError) {
+	return response{"usergroups": s.usergroups}, nil
+}
+
+// usersInfo describes one user, or several when the users parameter lists them
+func (s *Server) usersInfo(r *request) (response, *slackError) {
+	if list := r.params.Get("users"); list != "" {
+		var users []*slack.User
+		for _, userID := range strings.Split(list, ",") {
+			user := s.user(userID)
+			if user == nil {
+				return nil, fail("user_not_found")
+			}
+			users = append(users, user)
+		}
+		return response{"users": users}, nil
+	}
+
+	user := s.user(r.params.Get("user"))
+	if user == nil {
+		return nil, fail("user_not_found")
+	}
+	return response{"user": user}, nil
+}
+
+// usersList lists the workspace's members
+func (s *Server) usersList(r *request) (response, *slackError) {
+	start, end, nextCursor, err := page(r.params, len(s.users), 1000)
+	if err != nil {
+		return nil, err
+	}
+	return response{
+		"members":           s.users[start:end],
+		"response_metadata": response{"next_cursor": nextCursor},
+	}, nil
+}
+
+// usersLookupByEmail finds a member by email address
+func (s *Server) usersLookupByEmail(r *request) (response, *slackError) {
+	email := r.params.Get("email")
+	for _, user := range s.users {
+		if email != "" && strings.EqualFold(user.Profile.Email, email) {
+			return response{"user": user}, nil
+		}
+	}
+	return nil, fail("users_not_found")
+}
@@ -0,0 +1,160 @@
+package fakeslack
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// The app the Socket Mode and Events API events are sent for
+const (
+	AppID    = "A0FAKE001"
+	AppToken = "xapp-fake-app-token"
+)
+
+// socketPath is where apps.connections.open points Socket Mode clients
+const socketPath = "/socket-mode"
+
+// socketPingInterval is how often the server pings Socket Mode clients, as Slack does to
+// show the connection is alive
+const socketPingInterval = 5 * time.Second
+
+// upgrader accepts the Origin: https://api.slack.com header Socket Mode clients send
+var upgrader = websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+// socketConn is a connected Socket Mode client
+type socketConn struct {
+	envelopes chan []byte
+}
+
+// appsConnectionsOpen answers apps.connections.open with the URL of the Socket Mode endpoint
+func (s *Server) appsConnectionsOpen(r *request) (response, *slackError) {
+	return response{"url": "ws" + strings.TrimPrefix(s.srv.URL, "http") + socketPath}, nil
+}
+
+// EventCallback wraps an inner event, e.g. {"type": "message", ...}, in the event_callback
+// payload Slack delivers over Socket Mode and the Events API, giving it the next event ID
+func (s *Server) EventCallback(event map[string]any) map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.eventCallback(event)
+}
+
+// eventCallback is EventCallback with s.mu held
+func (s *Server) eventCallback(event map[string]any) map[string]any {
+	s.eventCount++
+	return map[string]any{
+		"token":      "fake-verification-token",
+		"team_id":    TeamID,
+		"api_app_id": AppID,
+		"type":       "event_callback",
+		"event_id":   fmt.Sprintf("Ev%08d", s.eventCount),
+		"event_time": firstTimestamp.Unix(),
+		"event":      event,
+	}
+}
+
+// SendEvent delivers an inner event to the connected Socket Mode clients in an events_api
+// envelope, or to the next client to connect when none is, and returns its event ID
+func (s *Server) SendEvent(event map[string]any) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	payload := s.eventCallback(event)
+	envelope, _ := json.Marshal(map[string]any{
+		"envelope_id":              fmt.Sprintf("envelope-%d", s.eventCount),
+		"type":                     "events_api",
+		"accepts_response_payload": false,
+		"retry_attempt":            0,
+		"retry_reason":             "",
+		"payload":                  payload,
+	})
+	if len(s.sockets) == 0 {
+		s.pendingEnvelopes = append(s.pendingEnvelopes, envelope)
+	}
+	for conn := range s.sockets {
+		conn.envelopes <- envelope
+	}
+	return payload["event_id"].(string)
+}
+
+// Acks returns the envelope IDs Socket Mode clients acknowledged, in order
+func (s *Server) Acks() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.acks)
+}
+
+// serveSocketMode runs a Socket Mode connection: it says hello, pings the client, forwards
+// the envelopes SendEvent queues and records the client's acknowledgements
+func (s *Server) serveSocketMode(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+
+	conn := &socketConn{envelopes: make(chan []byte, 100)}
+	s.mu.Lock()
+	s.sockets[conn] = true
+	for _, envelope := range s.pendingEnvelopes {
+		conn.envelopes <- envelope
+	}
+	s.pendingEnvelopes = nil
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.sockets, conn)
+		s.mu.Unlock()
+	}()
+
+	hello := map[string]any{
+		"type":            "hello",
+		"num_connections": 1,
+		"connection_info": map[string]any{"app_id": AppID},
+		"debug_info":      map[string]any{"host": "fakeslack", "approximate_connection_time": 3600},
+	}
+	if ws.WriteJSON(hello) != nil || ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)) != nil {
+		return
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			var ack struct {
+				EnvelopeID string `json:"envelope_id"`
+			}
+			if err := ws.ReadJSON(&ack); err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.acks = append(s.acks, ack.EnvelopeID)
+			s.mu.Unlock()
+		}
+	}()
+
+	ticker := time.NewTicker(socketPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-s.done:
+			ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(time.Second))
+			return
+		case envelope := <-conn.envelopes:
+			if ws.WriteMessage(websocket.TextMessage, envelope) != nil {
+				return
+			}
+		case <-ticker.C:
+			if ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)) != nil {
+				return
+			}
+		}
+	}
+}
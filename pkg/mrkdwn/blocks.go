@@ -0,0 +1,101 @@
+package mrkdwn
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	// maxHeaderLength is the longest text a header block accepts
+	maxHeaderLength = 150
+	// maxSectionLength is the longest text a section block accepts
+	maxSectionLength = 3000
+	// MaxBlocks is the most blocks a message may carry
+	MaxBlocks = 50
+)
+
+// ConvertBlocks translates CommonMark into Block Kit blocks: headings become header
+// blocks and everything else becomes mrkdwn sections, split to fit Slack's size limits.
+// It returns false when the document needs more than MaxBlocks blocks; callers should
+// then fall back to Convert.
+func ConvertBlocks(markdown string) ([]slack.Block, bool) {
+	var blocks []slack.Block
+	for _, seg := range parse(markdown) {
+		switch seg.kind {
+		case segmentHeading:
+			text := seg.text
+			if utf8.RuneCountInString(text) > maxHeaderLength {
+				text = string([]rune(text)[:maxHeaderLength-1]) + "…"
+			}
+			if text == "" {
+				continue
+			}
+			blocks = append(blocks, slack.NewHeaderBlock(
+				slack.NewTextBlockObject(slack.PlainTextType, text, true, false),
+			))
+		default:
+			for _, chunk := range splitSection(seg) {
+				blocks = append(blocks, slack.NewSectionBlock(
+					slack.NewTextBlockObject(slack.MarkdownType, chunk, false, false), nil, nil,
+				))
+			}
+		}
+	}
+	return blocks, len(blocks) <= MaxBlocks
+}
+
+// splitSection renders a segment as mrkdwn chunks that each fit in a section block,
+// breaking on line boundaries and re-opening code fences across chunks
+func splitSection(seg segment) []string {
+	text := strings.Trim(seg.mrkdwn(), "\n")
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(text) <= maxSectionLength {
+		return []string{text}
+	}
+
+	fenced := seg.kind == segmentCode || seg.kind == segmentTable
+	if fenced {
+		text = strings.TrimSuffix(strings.TrimPrefix(text, "```\n"), "\n```")
+	}
+	limit := maxSectionLength
+	if fenced {
+		limit -= len("```\n\n```")
+	}
+
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() == 0 {
+			return
+		}
+		chunk := current.String()
+		if fenced {
+			chunk = "```\n" + chunk + "\n```"
+		}
+		chunks = append(chunks, chunk)
+		current.Reset()
+	}
+	for _, line := range strings.Split(text, "\n") {
+		// a single line longer than a section is hard-wrapped
+		for utf8.RuneCountInString(line) > limit {
+			flush()
+			runes := []rune(line)
+			current.WriteString(string(runes[:limit]))
+			flush()
+			line = string(runes[limit:])
+		}
+		if utf8.RuneCountInString(current.String())+utf8.RuneCountInString(line)+1 > limit {
+			flush()
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n")
+		}
+		current.WriteString(line)
+	}
+	flush()
+	return chunks
+}
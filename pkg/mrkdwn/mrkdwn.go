@@ -0,0 +1,473 @@
+// Package mrkdwn converts CommonMark, as produced by LLM clients, into Slack's mrkdwn
+// dialect and Block Kit blocks.
+//
+// Slack mrkdwn differs from Markdown in ways that make raw Markdown render badly:
+// bold is *x* rather than **x**, links are <url|text>, there are no headers or tables,
+// and &, < and > must be escaped. Convert handles these line by line; it is not a full
+// CommonMark implementation but covers what chat models typically write.
+package mrkdwn
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	headingPattern    = regexp.MustCompile(`^ {0,3}#{1,6}\s+(.*?)\s*#*\s*$`)
+	fencePattern      = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+	listItemPattern   = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	taskPattern       = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+	rulePattern       = regexp.MustCompile(`^ {0,3}(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	tableDelimPattern = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	quotePattern      = regexp.MustCompile(`^ {0,3}>\s?(.*)$`)
+)
+
+// bullets are the list markers used for each nesting level, cycling when nested deeper
+var bullets = []string{"•", "◦", "▪"}
+
+// Convert translates CommonMark into Slack mrkdwn text
+func Convert(markdown string) string {
+	var out []string
+	for _, seg := range parse(markdown) {
+		out = append(out, seg.mrkdwn())
+	}
+	return strings.Join(out, "\n")
+}
+
+// segmentKind is the kind of a top-level piece of a document
+type segmentKind int
+
+const (
+	segmentText segmentKind = iota
+	segmentHeading
+	segmentCode
+	segmentTable
+)
+
+// segment is a top-level piece of a document: a heading, a code block, a table, or a
+// run of other lines (paragraphs, lists, quotes) already converted to mrkdwn
+type segment struct {
+	kind segmentKind
+	text string     // converted mrkdwn for text, plain heading text, raw code
+	rows [][]string // table cells, header first
+}
+
+// mrkdwn renders a segment as mrkdwn text
+func (s segment) mrkdwn() string {
+	switch s.kind {
+	case segmentHeading:
+		return "*" + Escape(s.text) + "*"
+	case segmentCode:
+		return "```\n" + Escape(s.text) + "\n```"
+	case segmentTable:
+		return "```\n" + Escape(renderTable(s.rows)) + "\n```"
+	default:
+		return s.text
+	}
+}
+
+// parse splits a Markdown document into segments
+func parse(markdown string) []segment {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+
+	var segments []segment
+	var text []string
+	flushText := func() {
+		if len(text) > 0 {
+			segments = append(segments, segment{kind: segmentText, text: strings.Join(text, "\n")})
+			text = nil
+		}
+	}
+
+	var listIndents []int
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := fencePattern.FindStringSubmatch(line); m != nil {
+			// fenced code: everything up to the closing fence is kept verbatim
+			fence := m[1]
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			flushText()
+			segments = append(segments, segment{kind: segmentCode, text: strings.Join(code, "\n")})
+			listIndents = nil
+			continue
+		}
+
+		if i+1 < len(lines) && strings.Contains(line, "|") && tableDelimPattern.MatchString(lines[i+1]) {
+			rows := [][]string{splitTableRow(line)}
+			for i += 2; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+				rows = append(rows, splitTableRow(lines[i]))
+			}
+			i--
+			flushText()
+			segments = append(segments, segment{kind: segmentTable, rows: rows})
+			listIndents = nil
+			continue
+		}
+
+		if m := headingPattern.FindStringSubmatch(line); m != nil {
+			flushText()
+			segments = append(segments, segment{kind: segmentHeading, text: plainInline(m[1])})
+			listIndents = nil
+			continue
+		}
+
+		switch {
+		case rulePattern.MatchString(line):
+			text = append(text, "──────────")
+			listIndents = nil
+		case listItemPattern.MatchString(line):
+			m := listItemPattern.FindStringSubmatch(line)
+			indent := indentWidth(m[1])
+			// nesting follows the indentation of the enclosing items, however many spaces they used
+			for len(listIndents) > 0 && listIndents[len(listIndents)-1] > indent {
+				listIndents = listIndents[:len(listIndents)-1]
+			}
+			if len(listIndents) == 0 || listIndents[len(listIndents)-1] < indent {
+				listIndents = append(listIndents, indent)
+			}
+			text = append(text, listItem(len(listIndents)-1, m[2], m[3]))
+		case quotePattern.MatchString(line):
+			text = append(text, "> "+Inline(quotePattern.FindStringSubmatch(line)[1]))
+			listIndents = nil
+		default:
+			if strings.TrimSpace(line) == "" {
+				listIndents = nil
+			}
+			text = append(text, Inline(strings.TrimRight(line, " \t")))
+		}
+	}
+	flushText()
+	return segments
+}
+
+// listItem renders one list item at a nesting level
+func listItem(level int, marker, content string) string {
+	prefix := bullets[level%len(bullets)]
+	if marker[0] >= '0' && marker[0] <= '9' {
+		prefix = strings.TrimRight(marker, ".)") + "."
+	}
+	if m := taskPattern.FindStringSubmatch(content); m != nil {
+		prefix = "☐"
+		if m[1] != " " {
+			prefix = "☑"
+		}
+		content = m[2]
+	}
+	return strings.Repeat("    ", level) + prefix + " " + Inline(content)
+}
+
+// indentWidth measures leading whitespace, counting a tab as four spaces
+func indentWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if r == '\t' {
+			width += 4
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// splitTableRow splits a Markdown table row into trimmed, plain-text cells
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	cells := strings.Split(line, "|")
+	for i, cell := range cells {
+		cells[i] = plainInline(strings.TrimSpace(cell))
+	}
+	return cells
+}
+
+// renderTable lays a table out as aligned columns for a preformatted block
+func renderTable(rows [][]string) string {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var b strings.Builder
+	for r, row := range rows {
+		var cells []string
+		for i := range widths {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			cells = append(cells, cell+strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)))
+		}
+		b.WriteString(strings.TrimRight(strings.Join(cells, " | "), " "))
+		if r == 0 {
+			var rule []string
+			for _, w := range widths {
+				rule = append(rule, strings.Repeat("-", w))
+			}
+			b.WriteString("\n" + strings.Join(rule, "-+-"))
+		}
+		if r < len(rows)-1 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// Escape escapes the characters Slack treats as control sequences in message text
+func Escape(text string) string {
+	text = strings.ReplaceAll(text, "&", "&amp;")
+	text = strings.ReplaceAll(text, "<", "&lt;")
+	return strings.ReplaceAll(text, ">", "&gt;")
+}
+
+// plainInline strips inline Markdown down to plain text, for places that cannot hold
+// formatting such as headers and table cells
+func plainInline(text string) string {
+	var b strings.Builder
+	convertInline(&b, text, false)
+	return b.String()
+}
+
+// Inline converts inline Markdown (emphasis, code spans, links) within a single line to mrkdwn
+func Inline(text string) string {
+	var b strings.Builder
+	convertInline(&b, text, true)
+	return b.String()
+}
+
+// convertInline writes text to b, translating inline Markdown. With format unset it drops
+// formatting and writes plain unescaped text instead.
+func convertInline(b *strings.Builder, text string, format bool) {
+	write := func(s string) {
+		if format {
+			s = Escape(s)
+		}
+		b.WriteString(s)
+	}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_~[]()#+-.!|<>", rune(rest[1])):
+			write(rest[1:2])
+			i += 2
+			continue
+
+		case rest[0] == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			if end := strings.Index(rest[ticks:], rest[:ticks]); end >= 0 {
+				code := strings.TrimSpace(rest[ticks : ticks+end])
+				if format {
+					b.WriteString("`" + Escape(code) + "`")
+				} else {
+					b.WriteString(code)
+				}
+				i += ticks + end + ticks
+				continue
+			}
+
+		case rest[0] == '[' || strings.HasPrefix(rest, "!["):
+			if label, target, n, ok := parseLink(rest); ok {
+				if format {
+					b.WriteString("<" + escapeLinkTarget(target))
+					if label != "" && label != target {
+						b.WriteString("|" + Escape(plainInline(label)))
+					}
+					b.WriteString(">")
+				} else if label != "" {
+					b.WriteString(plainInline(label))
+				} else {
+					b.WriteString(target)
+				}
+				i += n
+				continue
+			}
+
+		case rest[0] == '<':
+			if end := strings.IndexByte(rest, '>'); end > 0 && isAutolink(rest[1:end]) {
+				target := rest[1:end]
+				if format {
+					if strings.Contains(target, "@") && !strings.Contains(target, "://") {
+						target = "mailto:" + target
+					}
+					b.WriteString("<" + escapeLinkTarget(target) + ">")
+				} else {
+					b.WriteString(target)
+				}
+				i += end + 1
+				continue
+			}
+
+		case strings.HasPrefix(rest, "~~"):
+			if inner, n, ok := delimited(text, i, "~~"); ok {
+				emphasis(b, inner, "~", format)
+				i += n
+				continue
+			}
+
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			if inner, n, ok := delimited(text, i, rest[:2]); ok {
+				emphasis(b, inner, "*", format)
+				i += n
+				continue
+			}
+
+		case rest[0] == '*' || rest[0] == '_':
+			if inner, n, ok := delimited(text, i, rest[:1]); ok {
+				emphasis(b, inner, "_", format)
+				i += n
+				continue
+			}
+		}
+
+		_, size := utf8.DecodeRuneInString(rest)
+		write(rest[:size])
+		i += size
+	}
+}
+
+// emphasis writes inner text wrapped in a mrkdwn emphasis marker
+func emphasis(b *strings.Builder, inner, marker string, format bool) {
+	if format {
+		b.WriteString(marker)
+	}
+	convertInline(b, inner, format)
+	if format {
+		b.WriteString(marker)
+	}
+}
+
+// delimited finds the emphasis span opened by delim at text[i:], returning its content and
+// total length. Like CommonMark, the opener must be followed and the closer preceded by
+// non-space, and underscores inside words (snake_case) do not count.
+func delimited(text string, i int, delim string) (string, int, bool) {
+	start := i + len(delim)
+	if start >= len(text) || text[start] == ' ' || text[start] == '\t' {
+		return "", 0, false
+	}
+	if delim[0] == '_' && i > 0 && isWordByte(text[i-1]) {
+		return "", 0, false
+	}
+
+	for j := start; j < len(text); j++ {
+		if text[j] == '`' {
+			// emphasis cannot close inside a code span
+			if end := strings.IndexByte(text[j+1:], '`'); end >= 0 {
+				j += end + 1
+			}
+			continue
+		}
+		if text[j] != delim[0] {
+			continue
+		}
+
+		// treat a run of delimiter characters as a unit so ***x*** nests as bold italic
+		run := len(text[j:]) - len(strings.TrimLeft(text[j:], delim[:1]))
+		if j == start || run < len(delim) || (len(delim) == 1 && run == 2) ||
+			text[j-1] == ' ' || text[j-1] == '\t' {
+			j += run - 1
+			continue
+		}
+		after := j + run
+		if delim[0] == '_' && after < len(text) && isWordByte(text[after]) {
+			j += run - 1
+			continue
+		}
+		closer := after - len(delim)
+		return text[start:closer], after - i, true
+	}
+	return "", 0, false
+}
+
+// parseLink parses [label](target) or ![alt](target) at the start of s
+func parseLink(s string) (label, target string, n int, ok bool) {
+	if strings.HasPrefix(s, "!") {
+		label, target, n, ok = parseLink(s[1:])
+		return label, target, n + 1, ok
+	}
+
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				if i+1 >= len(s) || s[i+1] != '(' {
+					return "", "", 0, false
+				}
+				end := closingParen(s[i+2:])
+				if end < 0 {
+					return "", "", 0, false
+				}
+				target = strings.TrimSpace(s[i+2 : i+2+end])
+				// drop an optional "title"
+				if sp := strings.IndexAny(target, " \t"); sp >= 0 {
+					target = target[:sp]
+				}
+				target = strings.Trim(target, "<>")
+				if target == "" {
+					return "", "", 0, false
+				}
+				return s[1:i], target, i + 2 + end + 1, true
+			}
+		}
+	}
+	return "", "", 0, false
+}
+
+// closingParen returns the index of the ) ending a link target, skipping balanced
+// parentheses inside it as in http://x/(y), or -1 when there is none
+func closingParen(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// isAutolink reports whether the contents of <...> are a URL or email autolink
+func isAutolink(s string) bool {
+	if strings.ContainsAny(s, " \t<") {
+		return false
+	}
+	return strings.Contains(s, "://") || strings.HasPrefix(s, "mailto:") ||
+		(strings.Count(s, "@") == 1 && strings.Contains(s[strings.IndexByte(s, '@'):], "."))
+}
+
+// escapeLinkTarget escapes the characters that would break out of a <url|text> token
+func escapeLinkTarget(target string) string {
+	target = strings.ReplaceAll(target, "|", "%7C")
+	return Escape(target)
+}
+
+// isWordByte reports whether b is part of a word for intraword emphasis rules
+func isWordByte(b byte) bool {
+	return b < utf8.RuneSelf && (unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b)))
+}
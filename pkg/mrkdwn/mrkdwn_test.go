@@ -0,0 +1,123 @@
+package mrkdwn
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/slack-go/slack"
+)
+
+func TestConvert(t *testing.T) {
+	for markdown, want := range map[string]string{
+		"**bold** and *italic*":             "*bold* and _italic_",
+		"__bold__ and _italic_ ~~struck~~":  "*bold* and _italic_ ~struck~",
+		"***both***":                        "*_both_*",
+		"snake_case_name stays":             "snake_case_name stays",
+		"a * b * c":                         "a * b * c",
+		"`**not bold**` & <tag>":            "`**not bold**` &amp; &lt;tag&gt;",
+		"\\*literal\\*":                     "*literal*",
+		"[docs](https://example.com/a?b=1)": "<https://example.com/a?b=1|docs>",
+		"[**bold** link](https://x.io)":     "<https://x.io|bold link>",
+		"[a](http://x/(y))":                 "<http://x/(y)|a>",
+		"[a](http://x/(y)) (after)":         "<http://x/(y)|a> (after)",
+		"[a](https://x.io \"title\")":       "<https://x.io|a>",
+		"[a](https://x.io/a|b)":             "<https://x.io/a%7Cb|a>",
+		"[https://x.io](https://x.io)":      "<https://x.io>",
+		"![alt](https://x.io/i.png)":        "<https://x.io/i.png|alt>",
+		"[not a link] (x)":                  "[not a link] (x)",
+		"<https://x.io> <me@x.io>":          "<https://x.io> <mailto:me@x.io>",
+		"# Title #":                         "*Title*",
+		"## **Bold** & <b>":                 "*Bold &amp; &lt;b&gt;*",
+		"> quoted **text**":                 "> quoted *text*",
+		"---":                               "──────────",
+		"- one\n- two\n  - nested\n    - deeper\n      - deepest\n- three":  "• one\n• two\n    ◦ nested\n        ▪ deeper\n            • deepest\n• three",
+		"1. first\n2) second\n   - sub":                                     "1. first\n2. second\n    ◦ sub",
+		"- [ ] todo\n- [x] done":                                            "☐ todo\n☑ done",
+		"\t- tab indented\n\t\t- nested":                                    "• tab indented\n    ◦ nested",
+		"```go\nif a < b && **c** {\n}\n```":                                "```\nif a &lt; b &amp;&amp; **c** {\n}\n```",
+		"~~~\nunclosed <fence>":                                             "```\nunclosed &lt;fence&gt;\n```",
+		"| Name | Qty |\n|---|--:|\n| **apple** | 3 |\n| pear & fig | 12 |": "```\nName       | Qty\n-----------+----\napple      | 3\npear &amp; fig | 12\n```",
+		"before\n| a | b |\n| - | - |\n| 1 | 2 |\n\nafter":                  "before\n```\na | b\n--+--\n1 | 2\n```\n\nafter",
+	} {
+		if got := Convert(markdown); got != want {
+			t.Errorf("Convert(%q) =\n%s\nwant\n%s", markdown, got, want)
+		}
+	}
+}
+
+func TestConvertBlocks(t *testing.T) {
+	blocks, ok := ConvertBlocks("# Report\n\nSome **text**.\n\n```\ncode\n```")
+	if !ok || len(blocks) != 3 {
+		t.Fatalf("ConvertBlocks returned %d blocks, %v; want 3, true", len(blocks), ok)
+	}
+	if header, isHeader := blocks[0].(*slack.HeaderBlock); !isHeader || header.Text.Text != "Report" || header.Text.Type != slack.PlainTextType {
+		t.Errorf("block 0 = %#v, want a plain text Report header", blocks[0])
+	}
+	for i, want := range map[int]string{1: "Some *text*.", 2: "```\ncode\n```"} {
+		if section, isSection := blocks[i].(*slack.SectionBlock); !isSection || section.Text.Text != want || section.Text.Type != slack.MarkdownType {
+			t.Errorf("block %d = %#v, want a mrkdwn section %q", i, blocks[i], want)
+		}
+	}
+
+	long := "# " + strings.Repeat("h", 200)
+	blocks, _ = ConvertBlocks(long)
+	if text := blocks[0].(*slack.HeaderBlock).Text.Text; utf8.RuneCountInString(text) != maxHeaderLength || !strings.HasSuffix(text, "…") {
+		t.Errorf("long header has %d runes, want %d ending in …", utf8.RuneCountInString(text), maxHeaderLength)
+	}
+
+	if _, ok := ConvertBlocks(strings.Repeat("# h\n", MaxBlocks+1)); ok {
+		t.Errorf("ConvertBlocks accepted %d blocks, want false past %d", MaxBlocks+1, MaxBlocks)
+	}
+}
+
+func TestConvertBlocksSplitsSections(t *testing.T) {
+	line := strings.Repeat("x", 99)
+	var paragraph, code []string
+	for i := 0; i < 70; i++ {
+		paragraph = append(paragraph, line)
+		code = append(code, "<"+line+">")
+	}
+
+	for name, tc := range map[string]struct {
+		markdown string
+		fenced   bool
+		chunks   int
+	}{
+		"paragraph":     {strings.Join(paragraph, "\n"), false, 3},
+		"code":          {"```\n" + strings.Join(code, "\n") + "\n```", true, 3},
+		"one long line": {strings.Repeat("y", 2*maxSectionLength+10), false, 3},
+	} {
+		blocks, ok := ConvertBlocks(tc.markdown)
+		if !ok || len(blocks) != tc.chunks {
+			t.Errorf("%s: split into %d blocks, %v; want %d", name, len(blocks), ok, tc.chunks)
+			continue
+		}
+		var joined []string
+		for i, block := range blocks {
+			text := block.(*slack.SectionBlock).Text.Text
+			if n := utf8.RuneCountInString(text); n > maxSectionLength {
+				t.Errorf("%s: block %d has %d runes, over %d", name, i, n, maxSectionLength)
+			}
+			if tc.fenced {
+				if !strings.HasPrefix(text, "```\n") || !strings.HasSuffix(text, "\n```") {
+					t.Errorf("%s: block %d is not a closed code fence", name, i)
+				}
+				text = strings.TrimSuffix(strings.TrimPrefix(text, "```\n"), "\n```")
+			}
+			joined = append(joined, text)
+		}
+		// nothing is lost or reordered across the chunks
+		want := strings.Trim(Convert(tc.markdown), "\n")
+		if tc.fenced {
+			want = strings.TrimSuffix(strings.TrimPrefix(want, "```\n"), "\n```")
+		}
+		sep := "\n"
+		if name == "one long line" {
+			sep = ""
+		}
+		if got := strings.Join(joined, sep); got != want {
+			t.Errorf("%s: chunks do not add up to the converted text", name)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// API is the subset of the slack-go client that Client calls. *slack.Client implements it;
+// tests can substitute their own implementation with OptionAPI.
+type API interface {
+	AddReactionContext(ctx context.Context, name string, item slack.ItemRef) error
+	DeleteScheduledMessageContext(ctx context.Context, params *slack.DeleteScheduledMessageParameters) (bool, error)
+	GetConversationHistoryContext(ctx context.Context, params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error)
+	GetConversationInfoContext(ctx context.Context, input *slack.GetConversationInfoInput) (*slack.Channel, error)
+	GetConversationRepliesContext(ctx context.Context, params *slack.GetConversationRepliesParameters) ([]slack.Message, bool, string, error)
+	GetConversationsContext(ctx context.Context, params *slack.GetConversationsParameters) ([]slack.Channel, string, error)
+	GetPermalinkContext(ctx context.Context, params *slack.PermalinkParameters) (string, error)
+	GetReactionsContext(ctx context.Context, item slack.ItemRef, params slack.GetReactionsParameters) ([]slack.ItemReaction, error)
+	GetScheduledMessagesContext(ctx context.Context, params *slack.GetScheduledMessagesParameters) ([]slack.ScheduledMessage, string, error)
+	GetUserByEmailContext(ctx context.Context, email string) (*slack.User, error)
+	GetUserGroupsContext(ctx context.Context, options ...slack.GetUserGroupsOption) ([]slack.UserGroup, error)
+	GetUserInfoContext(ctx context.Context, user string) (*slack.User, error)
+	GetUsersInfoContext(ctx context.Context, users ...string) (*[]slack.User, error)
+	PostMessageContext(ctx context.Context, channelID string, options ...slack.MsgOption) (string, string, error)
+	RemoveReactionContext(ctx context.Context, name string, item slack.ItemRef) error
+	SearchMessagesContext(ctx context.Context, query string, params slack.SearchParameters) (*slack.SearchMessages, error)
+}
+
+var _ API = (*slack.Client)(nil)
+
+// callMethod calls a Web API method directly, for the parameters slack-go does not expose
+// (e.g. a starting cursor for users.list). out must embed slack.SlackResponse.
+// It returns the response headers so callers can read metadata such as X-OAuth-Scopes.
+func (c *Client) callMethod(ctx context.Context, method string, values url.Values, out interface{ Err() error }) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+method, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// mirror slack-go's error types so callers handle both paths the same way
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter, _ := strconv.ParseInt(resp.Header.Get("Retry-After"), 10, 64)
+		return resp.Header, &slack.RateLimitedError{RetryAfter: time.Duration(retryAfter) * time.Second}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp.Header, slack.StatusCodeError{Code: resp.StatusCode, Status: resp.Status}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.Header, fmt.Errorf("failed to read %s response: %w", method, err)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return resp.Header, fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	err = out.Err()
+	var slackErr slack.SlackErrorResponse
+	if errors.As(err, &slackErr) && slackErr.Err == "missing_scope" {
+		return resp.Header, missingScopeErrorOf(body)
+	}
+	return resp.Header, err
+}
+
+// missingScopeErrorOf reads the needed and provided scopes Slack adds to a missing_scope response
+func missingScopeErrorOf(body []byte) *MissingScopeError {
+	var scopes struct {
+		Needed   string `json:"needed"`
+		Provided string `json:"provided"`
+	}
+	// callMethod decoded the body already; should this pass fail all the same, the error
+	// is still a missing_scope one, only without the scope lists
+	_ = json.Unmarshal(body, &scopes)
+	return &MissingScopeError{
+		Needed:   splitScopes(scopes.Needed),
+		Provided: splitScopes(scopes.Provided),
+	}
+}
+
+// splitScopes splits a comma-separated scope list as found in X-OAuth-Scopes and missing_scope responses
+func splitScopes(list string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(list, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,181 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/slack-go/slack"
+)
+
+// Block Kit limits enforced by chat.postMessage
+const (
+	maxMessageBlocks     = 50
+	maxBlockIDLength     = 255
+	maxSectionTextLength = 3000
+	maxSectionFields     = 10
+	maxFieldTextLength   = 2000
+	maxHeaderTextLength  = 150
+	maxContextElements   = 10
+	maxActionsElements   = 25
+)
+
+// messageBlockTypes are the block types Slack accepts in messages
+var messageBlockTypes = map[string]bool{
+	"actions":   true,
+	"context":   true,
+	"divider":   true,
+	"file":      true,
+	"header":    true,
+	"image":     true,
+	"markdown":  true,
+	"rich_text": true,
+	"section":   true,
+	"video":     true,
+}
+
+// rawBlock is a validated block sent to Slack exactly as the caller wrote it,
+// so fields slack-go does not model survive the round trip
+type rawBlock struct {
+	blockType string
+	raw       json.RawMessage
+}
+
+// BlockType returns the type of the block
+func (b rawBlock) BlockType() slack.MessageBlockType {
+	return slack.MessageBlockType(b.blockType)
+}
+
+// MarshalJSON returns the block as the caller wrote it
+func (b rawBlock) MarshalJSON() ([]byte, error) {
+	return b.raw, nil
+}
+
+// ParseBlocks validates a Block Kit JSON array against the parts of Slack's schema that
+// chat.postMessage enforces. Errors name the offending block, e.g. "blocks[2] (header): ...".
+func ParseBlocks(data []byte) ([]slack.Block, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return nil, fmt.Errorf("blocks must be a JSON array of block objects: %w", err)
+	}
+	if len(raws) > maxMessageBlocks {
+		return nil, fmt.Errorf("blocks[%d]: a message can have at most %d blocks, got %d", maxMessageBlocks, maxMessageBlocks, len(raws))
+	}
+
+	blocks := make([]slack.Block, 0, len(raws))
+	blockIDs := make(map[string]int, len(raws))
+	for i, raw := range raws {
+		var header struct {
+			Type    string `json:"type"`
+			BlockID string `json:"block_id"`
+		}
+		if err := json.Unmarshal(raw, &header); err != nil {
+			return nil, fmt.Errorf("blocks[%d]: must be a JSON object: %w", i, err)
+		}
+		if header.Type == "" {
+			return nil, fmt.Errorf("blocks[%d]: missing type", i)
+		}
+		if !messageBlockTypes[header.Type] {
+			return nil, fmt.Errorf("blocks[%d] (%s): block type is not allowed in messages", i, header.Type)
+		}
+		if len(header.BlockID) > maxBlockIDLength {
+			return nil, fmt.Errorf("blocks[%d] (%s): block_id exceeds %d characters", i, header.Type, maxBlockIDLength)
+		}
+		if header.BlockID != "" {
+			if j, ok := blockIDs[header.BlockID]; ok {
+				return nil, fmt.Errorf("blocks[%d] (%s): block_id %q is already used by blocks[%d]", i, header.Type, header.BlockID, j)
+			}
+			blockIDs[header.BlockID] = i
+		}
+
+		// let slack-go decode the block into its typed form to catch malformed fields
+		var parsed slack.Blocks
+		if err := json.Unmarshal([]byte("["+string(raw)+"]"), &parsed); err != nil {
+			return nil, fmt.Errorf("blocks[%d] (%s): %w", i, header.Type, err)
+		}
+		if len(parsed.BlockSet) == 1 {
+			if err := validateBlock(parsed.BlockSet[0]); err != nil {
+				return nil, fmt.Errorf("blocks[%d] (%s): %w", i, header.Type, err)
+			}
+		}
+
+		blocks = append(blocks, rawBlock{blockType: header.Type, raw: raw})
+	}
+	return blocks, nil
+}
+
+// validateBlock checks the required fields and size limits of the common block types
+func validateBlock(block slack.Block) error {
+	switch b := block.(type) {
+	case *slack.SectionBlock:
+		if b.Text == nil && len(b.Fields) == 0 {
+			return fmt.Errorf("a section needs text or fields")
+		}
+		if b.Text != nil {
+			if err := validateTextObject("text", b.Text, maxSectionTextLength); err != nil {
+				return err
+			}
+		}
+		if len(b.Fields) > maxSectionFields {
+			return fmt.Errorf("fields can have at most %d items, got %d", maxSectionFields, len(b.Fields))
+		}
+		for i, field := range b.Fields {
+			if err := validateTextObject(fmt.Sprintf("fields[%d]", i), field, maxFieldTextLength); err != nil {
+				return err
+			}
+		}
+	case *slack.HeaderBlock:
+		if b.Text == nil {
+			return fmt.Errorf("a header needs text")
+		}
+		if b.Text.Type != slack.PlainTextType {
+			return fmt.Errorf("text must be plain_text, got %q", b.Text.Type)
+		}
+		if err := validateTextObject("text", b.Text, maxHeaderTextLength); err != nil {
+			return err
+		}
+	case *slack.ImageBlock:
+		if b.ImageURL == "" {
+			return fmt.Errorf("an image needs image_url")
+		}
+		if b.AltText == "" {
+			return fmt.Errorf("an image needs alt_text")
+		}
+	case *slack.ContextBlock:
+		if n := len(b.ContextElements.Elements); n == 0 || n > maxContextElements {
+			return fmt.Errorf("elements must have between 1 and %d items, got %d", maxContextElements, n)
+		}
+	case *slack.ActionBlock:
+		if b.Elements == nil || len(b.Elements.ElementSet) == 0 || len(b.Elements.ElementSet) > maxActionsElements {
+			n := 0
+			if b.Elements != nil {
+				n = len(b.Elements.ElementSet)
+			}
+			return fmt.Errorf("elements must have between 1 and %d items, got %d", maxActionsElements, n)
+		}
+	}
+	return nil
+}
+
+// validateTextObject checks a text composition object's type and length
+func validateTextObject(name string, text *slack.TextBlockObject, maxLength int) error {
+	if text.Type != slack.PlainTextType && text.Type != slack.MarkdownType {
+		return fmt.Errorf("%s.type must be plain_text or mrkdwn, got %q", name, text.Type)
+	}
+	if text.Text == "" {
+		return fmt.Errorf("%s.text must not be empty", name)
+	}
+	if n := utf8.RuneCountInString(text.Text); n > maxLength {
+		return fmt.Errorf("%s.text exceeds %d characters (%d)", name, maxLength, n)
+	}
+	return nil
+}
+
+// ParseAttachments decodes a legacy attachments JSON array
+func ParseAttachments(data []byte) ([]slack.Attachment, error) {
+	var attachments []slack.Attachment
+	if err := json.Unmarshal(data, &attachments); err != nil {
+		return nil, fmt.Errorf("attachments must be a JSON array of attachment objects: %w", err)
+	}
+	return attachments, nil
+}
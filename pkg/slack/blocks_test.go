@@ -0,0 +1,80 @@
+package slack
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseBlocks(t *testing.T) {
+	section := func(text string) string {
+		return `{"type":"section","text":{"type":"mrkdwn","text":"` + text + `"}}`
+	}
+	var tooMany []string
+	for i := 0; i <= maxMessageBlocks; i++ {
+		tooMany = append(tooMany, `{"type":"divider"}`)
+	}
+
+	valid := `[` + section("*hello*") + `,{"type":"divider","block_id":"d1"},` +
+		`{"type":"header","text":{"type":"plain_text","text":"Title"}},` +
+		`{"type":"context","elements":[{"type":"mrkdwn","text":"note"}]},` +
+		`{"type":"table","rows":[]}]`
+	if _, err := ParseBlocks([]byte(valid)); err == nil || !strings.HasPrefix(err.Error(), "blocks[4] (table): ") {
+		t.Errorf("ParseBlocks accepted a table block: %v", err)
+	}
+	blocks, err := ParseBlocks([]byte(strings.Replace(valid, `,{"type":"table","rows":[]}`, "", 1)))
+	if err != nil || len(blocks) != 4 {
+		t.Fatalf("ParseBlocks(valid) = %d blocks, %v", len(blocks), err)
+	}
+	// blocks are sent as written, including fields slack-go does not model
+	if data, err := json.Marshal(blocks[1]); err != nil || string(data) != `{"type":"divider","block_id":"d1"}` {
+		t.Errorf("divider marshals to %s, %v", data, err)
+	}
+
+	for name, tc := range map[string]struct {
+		blocks string
+		prefix string
+	}{
+		"invalid JSON":      {`[{"type":"section"`, "blocks must be a JSON array"},
+		"not an array":      {section("x"), "blocks must be a JSON array"},
+		"not an object":     {`[` + section("x") + `,"divider"]`, "blocks[1]: "},
+		"missing type":      {`[` + section("x") + `,{"block_id":"b"}]`, "blocks[1]: missing type"},
+		"unknown type":      {`[{"type":"table"}]`, "blocks[0] (table): "},
+		"input in messages": {`[` + section("x") + `,{"type":"input"}]`, "blocks[1] (input): "},
+		"duplicate block_id": {
+			`[{"type":"divider","block_id":"b1"},{"type":"divider","block_id":"b2"},{"type":"divider","block_id":"b1"}]`,
+			`blocks[2] (divider): block_id "b1" is already used by blocks[0]`,
+		},
+		"long block_id":       {`[{"type":"divider","block_id":"` + strings.Repeat("b", maxBlockIDLength+1) + `"}]`, "blocks[0] (divider): block_id exceeds"},
+		"too many blocks":     {`[` + strings.Join(tooMany, ",") + `]`, "blocks[50]: a message can have at most 50 blocks"},
+		"mrkdwn header":       {`[{"type":"header","text":{"type":"mrkdwn","text":"*Title*"}}]`, "blocks[0] (header): text must be plain_text"},
+		"header without text": {`[{"type":"divider"},{"type":"header"}]`, "blocks[1] (header): a header needs text"},
+		"long header":         {`[{"type":"header","text":{"type":"plain_text","text":"` + strings.Repeat("h", maxHeaderTextLength+1) + `"}}]`, "blocks[0] (header): text.text exceeds 150"},
+		"oversized section":   {`[{"type":"divider"},` + section(strings.Repeat("s", maxSectionTextLength+1)) + `]`, "blocks[1] (section): text.text exceeds 3000"},
+		"empty section":       {`[{"type":"section"}]`, "blocks[0] (section): a section needs text or fields"},
+		"empty section text":  {`[` + section("") + `]`, "blocks[0] (section): text.text must not be empty"},
+		"bad field type":      {`[{"type":"section","fields":[{"type":"markdown","text":"x"}]}]`, "blocks[0] (section): fields[0].type must be"},
+		"empty context":       {`[` + section("x") + `,{"type":"context","elements":[]}]`, "blocks[1] (context): elements must have between 1 and 10 items, got 0"},
+		"image without alt":   {`[{"type":"image","image_url":"https://x.io/i.png"}]`, "blocks[0] (image): an image needs alt_text"},
+		"empty actions":       {`[{"type":"actions","elements":[]}]`, "blocks[0] (actions): elements must have between 1 and 25"},
+	} {
+		blocks, err := ParseBlocks([]byte(tc.blocks))
+		if err == nil {
+			t.Errorf("%s: ParseBlocks returned %d blocks, want an error starting %q", name, len(blocks), tc.prefix)
+		} else if !strings.HasPrefix(err.Error(), tc.prefix) {
+			t.Errorf("%s: error %q, want it to start with %q", name, err, tc.prefix)
+		}
+	}
+}
+
+func TestParseAttachments(t *testing.T) {
+	attachments, err := ParseAttachments([]byte(`[{"color":"#36a64f","title":"Build","text":"passed","fields":[{"title":"branch","value":"main","short":true}]}]`))
+	if err != nil || len(attachments) != 1 || attachments[0].Title != "Build" || attachments[0].Fields[0].Value != "main" {
+		t.Errorf("ParseAttachments = %+v, %v", attachments, err)
+	}
+	for _, data := range []string{`{"title":"Build"}`, `[{"title":`, `["text"]`} {
+		if _, err := ParseAttachments([]byte(data)); err == nil || !strings.HasPrefix(err.Error(), "attachments must be a JSON array") {
+			t.Errorf("ParseAttachments(%s) = %v, want an error", data, err)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// channelDirectoryTTL is how long a cached conversations.list snapshot is reused
+const channelDirectoryTTL = 5 * time.Minute
+
+// AllConversationTypes are the conversation types the resolver searches by default
+var AllConversationTypes = []string{"public_channel", "private_channel", "mpim", "im"}
+
+// channelDirectory caches conversations.list snapshots, one per combination of types
+type channelDirectory struct {
+	mu        sync.Mutex
+	snapshots map[string]*channelSnapshot
+}
+
+// channelSnapshot is the list of conversations of some types at a point in time
+type channelSnapshot struct {
+	channels []slack.Channel
+	loadedAt time.Time
+}
+
+// cachedChannels returns every conversation of the given types the token can see,
+// archived ones included, reloading the snapshot once it expires
+func (c *Client) cachedChannels(ctx context.Context, types []string) ([]slack.Channel, error) {
+	key := strings.Join(types, ",")
+
+	c.channels.mu.Lock()
+	defer c.channels.mu.Unlock()
+
+	if snapshot, ok := c.channels.snapshots[key]; ok && time.Since(snapshot.loadedAt) < channelDirectoryTTL {
+		return snapshot.channels, nil
+	}
+
+	params := &slack.GetConversationsParameters{
+		Limit: 1000,
+		Types: types,
+	}
+	var channels []slack.Channel
+	for {
+		page, nextCursor, err := c.api.GetConversationsContext(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load conversations: %w", err)
+		}
+		channels = append(channels, page...)
+		if nextCursor == "" {
+			break
+		}
+		params.Cursor = nextCursor
+	}
+
+	if c.channels.snapshots == nil {
+		c.channels.snapshots = make(map[string]*channelSnapshot)
+	}
+	c.channels.snapshots[key] = &channelSnapshot{channels: channels, loadedAt: time.Now()}
+	return channels, nil
+}
+
+// FindChannels looks up conversations by ID, permalink, #name, bare name or, for direct
+// messages, @user. Exact matches come first, followed by channels whose name contains the query.
+// types limits the search to some conversation types; empty means AllConversationTypes.
+func (c *Client) FindChannels(ctx context.Context, query string, types []string) ([]*ChannelMatch, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if len(types) == 0 {
+		types = AllConversationTypes
+	}
+
+	if channelID, ok := channelIDOf(query); ok {
+		channel, err := c.api.GetConversationInfoContext(ctx, &slack.GetConversationInfoInput{ChannelID: channelID})
+		if err == nil {
+			return []*ChannelMatch{newChannelMatch(channel, true)}, nil
+		}
+		if !channelIDPattern.MatchString(query) || !isChannelNotFound(err) {
+			return nil, err
+		}
+		// a name typed in capitals, such as CORPORATE, looks like an ID too
+		matches, nameErr := c.findChannelsByName(ctx, query, types)
+		if nameErr != nil || len(matches) == 0 {
+			return nil, err
+		}
+		return matches, nil
+	}
+	return c.findChannelsByName(ctx, query, types)
+}
+
+// findChannelsByName looks up conversations by #name, bare name or @user
+func (c *Client) findChannelsByName(ctx context.Context, query string, types []string) ([]*ChannelMatch, error) {
+	channels, err := c.cachedChannels(ctx, types)
+	if err != nil {
+		return nil, err
+	}
+
+	// direct messages have no name, so @user resolves the user and finds their IM
+	if strings.HasPrefix(query, "@") {
+		userID, err := c.ResolveUserID(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		for i := range channels {
+			if channels[i].IsIM && channels[i].User == userID {
+				return []*ChannelMatch{newChannelMatch(&channels[i], true)}, nil
+			}
+		}
+		return nil, nil
+	}
+
+	name := normalizeChannelName(query)
+	var matches []*ChannelMatch
+	for i := range channels {
+		channelName := strings.ToLower(channels[i].Name)
+		switch {
+		case channelName == "":
+			continue
+		case channelName == name:
+			matches = append(matches, newChannelMatch(&channels[i], true))
+		case strings.Contains(channelName, name):
+			matches = append(matches, newChannelMatch(&channels[i], false))
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Exact != matches[j].Exact {
+			return matches[i].Exact
+		}
+		// prefer live channels over archived ones with a similar name
+		return !matches[i].IsArchived && matches[j].IsArchived
+	})
+	return matches, nil
+}
+
+// ResolveChannelID turns a channel ID, permalink, #name, bare name or @user (for a direct
+// message) into a conversation ID. Names must match exactly. IDs from permalinks and IDs of
+// conversations already listed pass through without an API call; other IDs are checked with
+// conversations.info, since a name typed in capitals (CORPORATE) has the shape of an ID.
+func (c *Client) ResolveChannelID(ctx context.Context, identifier string) (string, error) {
+	identifier = strings.TrimSpace(identifier)
+	var notFound error
+	if channelID, ok := channelIDOf(identifier); ok {
+		if !channelIDPattern.MatchString(identifier) || c.knownChannelID(channelID) {
+			return channelID, nil
+		}
+		// without channels:read the ID cannot be checked, so it is used as given
+		_, err := c.api.GetConversationInfoContext(ctx, &slack.GetConversationInfoInput{ChannelID: channelID})
+		if !isChannelNotFound(err) {
+			return channelID, nil
+		}
+		notFound = err
+	}
+
+	matches, err := c.findChannelsByName(ctx, identifier, AllConversationTypes)
+	if err != nil {
+		return "", errors.Join(notFound, err)
+	}
+	var exact []*ChannelMatch
+	for _, match := range matches {
+		if match.Exact {
+			exact = append(exact, match)
+		}
+	}
+	switch {
+	case len(exact) == 0 && notFound != nil:
+		return "", notFound
+	case len(exact) == 0:
+		return "", fmt.Errorf("no conversation named %q is visible to this token", identifier)
+	case len(exact) == 1:
+		return exact[0].ID, nil
+	default:
+		ids := make([]string, 0, len(exact))
+		for _, match := range exact {
+			ids = append(ids, match.ID)
+		}
+		return "", fmt.Errorf("%q matches several conversations: %s", identifier, strings.Join(ids, ", "))
+	}
+}
+
+// channelIDOf extracts a conversation ID from a raw ID or a message permalink
+func channelIDOf(identifier string) (string, bool) {
+	identifier = strings.TrimSpace(identifier)
+	if channelIDPattern.MatchString(identifier) {
+		return identifier, true
+	}
+	if strings.Contains(identifier, "://") {
+		if link, err := ParsePermalink(identifier); err == nil {
+			return link.ChannelID, true
+		}
+	}
+	return "", false
+}
+
+// knownChannelID reports whether a cached conversations.list snapshot holds the conversation
+func (c *Client) knownChannelID(channelID string) bool {
+	c.channels.mu.Lock()
+	defer c.channels.mu.Unlock()
+	for _, snapshot := range c.channels.snapshots {
+		for i := range snapshot.channels {
+			if snapshot.channels[i].ID == channelID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isChannelNotFound reports whether Slack answered that no conversation has the ID
+func isChannelNotFound(err error) bool {
+	var slackErr slack.SlackErrorResponse
+	return errors.As(err, &slackErr) && slackErr.Err == "channel_not_found"
+}
+
+// normalizeChannelName strips the decorations people type around channel names
+func normalizeChannelName(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+}
+
+// conversationType names the kind of a conversation in conversations.list terms
+func conversationType(channel *slack.Channel) string {
+	switch {
+	case channel.IsIM:
+		return "im"
+	case channel.IsMpIM:
+		return "mpim"
+	case channel.IsPrivate:
+		return "private_channel"
+	default:
+		return "public_channel"
+	}
+}
+
+// newChannelMatch builds a lookup result from a conversation
+func newChannelMatch(channel *slack.Channel, exact bool) *ChannelMatch {
+	return &ChannelMatch{
+		ID:         channel.ID,
+		Name:       channel.Name,
+		Type:       conversationType(channel),
+		User:       channel.User,
+		IsArchived: channel.IsArchived,
+		Exact:      exact,
+	}
+}
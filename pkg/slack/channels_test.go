@@ -0,0 +1,125 @@
+package slack
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+
+	"github.com/shawnzhang/slack-go/pkg/fakeslack"
+)
+
+// newTestClient returns a bot client of a fake workspace with a few channels, some of whose
+// names look like IDs once typed in capitals
+func newTestClient(t *testing.T) (*Client, *fakeslack.Server) {
+	t.Helper()
+	fake := fakeslack.NewServer()
+	t.Cleanup(fake.Close)
+
+	channel := func(id, name string, archived, private bool) slack.Channel {
+		return slack.Channel{GroupConversation: slack.GroupConversation{
+			Conversation: slack.Conversation{ID: id, IsPrivate: private},
+			Name:         name,
+			IsArchived:   archived,
+		}}
+	}
+	fake.AddChannel(channel("C0GENERAL1", "general", false, false))
+	fake.AddChannel(channel("C0OLDGEN01", "general-old", true, false))
+	fake.AddChannel(channel("C0DEVGEN01", "dev-general", false, false))
+	fake.AddChannel(channel("C0GENRL001", "general1", false, false))
+	fake.AddChannel(channel("C0CORP0001", "corporate", false, false))
+	fake.AddChannel(channel("G0SECRET01", "secret", false, true))
+	fake.AddChannel(slack.Channel{GroupConversation: slack.GroupConversation{
+		Conversation: slack.Conversation{ID: "D0OWNER001", IsIM: true, User: fakeslack.UserID},
+	}}, fakeslack.BotUserID, fakeslack.UserID)
+
+	return NewClient(fakeslack.BotToken, OptionAPIURL(fake.URL)), fake
+}
+
+func TestFindChannels(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	for query, want := range map[string]string{
+		"general":    "C0GENERAL1* C0DEVGEN01 C0GENRL001 C0OLDGEN01",
+		"#GENERAL":   "C0GENERAL1* C0DEVGEN01 C0GENRL001 C0OLDGEN01",
+		"C0GENERAL1": "C0GENERAL1*",
+		"https://example.slack.com/archives/C0CORP0001/p1742788004223029": "C0CORP0001*",
+		"CORPORATE": "C0CORP0001*",
+		"GENERAL1":  "C0GENRL001*",
+		"@owner":    "D0OWNER001*",
+		"secret":    "",
+		"nowhere":   "",
+	} {
+		matches, err := client.FindChannels(ctx, query, nil)
+		if err != nil {
+			t.Errorf("FindChannels(%q): %v", query, err)
+			continue
+		}
+		var got []string
+		for _, match := range matches {
+			if match.Exact {
+				got = append(got, match.ID+"*")
+			} else {
+				got = append(got, match.ID)
+			}
+		}
+		if strings.Join(got, " ") != want {
+			t.Errorf("FindChannels(%q) = %s, want %s", query, strings.Join(got, " "), want)
+		}
+	}
+
+	for _, query := range []string{"C0MISSING1", "G0SECRET01"} {
+		if _, err := client.FindChannels(ctx, query, nil); DescribeError(err).Code != "channel_not_found" {
+			t.Errorf("FindChannels(%q) = %v, want channel_not_found", query, err)
+		}
+	}
+}
+
+func TestResolveChannelID(t *testing.T) {
+	client, fake := newTestClient(t)
+	ctx := context.Background()
+
+	for identifier, want := range map[string]string{
+		"C0GENERAL1": "C0GENERAL1",
+		"#general":   "C0GENERAL1",
+		" General ":  "C0GENERAL1",
+		"CORPORATE":  "C0CORP0001",
+		"#corporate": "C0CORP0001",
+		"GENERAL1":   "C0GENRL001",
+		"@owner":     "D0OWNER001",
+		"https://example.slack.com/archives/C0GENERAL1/p1742788004223029?thread_ts=1742788000.000100&cid=C0GENERAL1": "C0GENERAL1",
+	} {
+		if got, err := client.ResolveChannelID(ctx, identifier); err != nil || got != want {
+			t.Errorf("ResolveChannelID(%q) = %q, %v; want %s", identifier, got, err, want)
+		}
+	}
+
+	for identifier, code := range map[string]string{
+		"C0MISSING1":  "channel_not_found",
+		"G0SECRET01":  "channel_not_found",
+		"dev":         "request_failed",
+		"general-new": "request_failed",
+	} {
+		if got, err := client.ResolveChannelID(ctx, identifier); DescribeError(err).Code != code {
+			t.Errorf("ResolveChannelID(%q) = %q, %v; want %s", identifier, got, err, code)
+		}
+	}
+
+	// IDs of listed conversations are not checked again
+	calls := len(fake.Calls("conversations.info"))
+	if got, err := client.ResolveChannelID(ctx, "C0DEVGEN01"); err != nil || got != "C0DEVGEN01" {
+		t.Errorf("ResolveChannelID(C0DEVGEN01) = %q, %v", got, err)
+	}
+	if n := len(fake.Calls("conversations.info")) - calls; n != 0 {
+		t.Errorf("listed ID took %d conversations.info calls, want none", n)
+	}
+
+	// an ID that cannot be checked without channels:read is used as given
+	fake.SetScopes(fakeslack.BotToken, "chat:write")
+	scopeless := NewClient(fakeslack.BotToken, OptionAPIURL(fake.URL))
+	if got, err := scopeless.ResolveChannelID(ctx, "C0GENERAL1"); err != nil || got != "C0GENERAL1" {
+		t.Errorf("ResolveChannelID without channels:read = %q, %v; want C0GENERAL1", got, err)
+	}
+}
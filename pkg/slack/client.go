@@ -1,130 +1,496 @@
 package slack
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/slack-go/slack"
 )
 
+// threadRepliesPageSize is the number of messages requested per conversations.replies call
+const threadRepliesPageSize = 200
+
+// ErrUserTokenRequired is returned by methods that Slack only allows for user (xoxp-) tokens
+var ErrUserTokenRequired = errors.New("this method requires a user token (xoxp-), but the server is configured with a bot token (xoxb-)")
+
 // Client wraps the slack client with our custom methods
 type Client struct {
-	api *slack.Client
+	api        API
+	token      string
+	tokenType  TokenType
+	scopes     []string
+	apiURL     string
+	httpClient *http.Client
+	scheduler  *scheduler
+	directory  userDirectory
+	channels   channelDirectory
+	mentions   mentionCache
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// OptionAPIURL sends every call to another Web API base URL, e.g. https://slack-gov.com/api/
+// or a fake Slack server in tests. The URL must end with a slash.
+func OptionAPIURL(apiURL string) Option {
+	return func(c *Client) {
+		c.apiURL = apiURL
+	}
 }
 
-// NewClient creates a new Slack client
-func NewClient(token string) *Client {
-	return &Client{
-		api: slack.New(token),
+// OptionAPI replaces the slack-go client the Client calls. Methods slack-go does not cover,
+// such as AuthTest and GetUsers, still go to the API URL.
+func OptionAPI(api API) Option {
+	return func(c *Client) {
+		c.api = api
+	}
+}
+
+// NewClient creates a new Slack client. Every call, through slack-go or callMethod, goes
+// through a scheduler that keeps the token within Slack's rate limits.
+func NewClient(token string, options ...Option) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// bounds each attempt; waiting in the rate-limit queue is bounded by the call's context instead
+	transport.ResponseHeaderTimeout = 30 * time.Second
+	scheduler := newScheduler(transport)
+
+	c := &Client{
+		token:      token,
+		tokenType:  tokenTypeOf(token),
+		apiURL:     slack.APIURL,
+		httpClient: &http.Client{Transport: scheduler},
+		scheduler:  scheduler,
+	}
+	for _, option := range options {
+		option(c)
 	}
+	if c.api == nil {
+		c.api = slack.New(token, slack.OptionHTTPClient(c.httpClient), slack.OptionAPIURL(c.apiURL))
+	}
+	return c
+}
+
+// RateLimitStats returns the rate-limit queue of every method called so far: how many
+// calls are waiting now, and how often calls were delayed, rate limited and retried
+func (c *Client) RateLimitStats() []RateLimitStats {
+	return c.scheduler.Stats()
 }
 
-// PostMessage posts a message to a channel
-func (c *Client) PostMessage(channelID, text string) (*Message, error) {
-	_, timestamp, err := c.api.PostMessage(
-		channelID,
-		slack.MsgOptionText(text, false),
-	)
+// TokenType returns the kind of token the client authenticates with
+func (c *Client) TokenType() TokenType {
+	return c.tokenType
+}
+
+// AuthTest returns who the token authenticates as: the user, the team and, on Enterprise Grid,
+// the organization, along with the OAuth scopes Slack reports in the X-OAuth-Scopes header.
+// Tokens without a recognizable prefix are classified as bot or user tokens from the response.
+func (c *Client) AuthTest(ctx context.Context) (*Identity, error) {
+	if c.tokenType == TokenTypeApp {
+		return nil, fmt.Errorf("app-level tokens (xapp-) can only open Socket Mode connections; use a bot (xoxb-) or user (xoxp-) token")
+	}
+
+	var response struct {
+		slack.SlackResponse
+		slack.AuthTestResponse
+	}
+	header, err := c.callMethod(ctx, "auth.test", url.Values{}, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Message{
-		Timestamp: timestamp,
-		Channel:   channelID,
-		Text:      text,
-	}, nil
+	if c.tokenType == TokenTypeUnknown {
+		if response.BotID != "" {
+			c.tokenType = TokenTypeBot
+		} else {
+			c.tokenType = TokenTypeUser
+		}
+	}
+
+	identity := &Identity{
+		URL:          response.URL,
+		Team:         response.Team,
+		TeamID:       response.TeamID,
+		User:         response.User,
+		UserID:       response.UserID,
+		EnterpriseID: response.EnterpriseID,
+		BotID:        response.BotID,
+		TokenType:    c.tokenType,
+	}
+	if raw, ok := header["X-Oauth-Scopes"]; ok {
+		identity.ScopesKnown = true
+		identity.Scopes = splitScopes(strings.Join(raw, ","))
+		sort.Strings(identity.Scopes)
+		c.scopes = identity.Scopes
+	}
+	return identity, nil
 }
 
-// PostReply posts a reply to a thread
-func (c *Client) PostReply(channelID, threadTS, text string) (*Message, error) {
-	_, timestamp, err := c.api.PostMessage(
-		channelID,
-		slack.MsgOptionText(text, false),
-		slack.MsgOptionTS(threadTS),
-	)
+// Scopes returns the OAuth scopes granted to the token, as of the last AuthTest; nil when unknown
+func (c *Client) Scopes() []string {
+	return c.scopes
+}
+
+// tokenTypeOf detects the kind of a Slack token from its prefix
+func tokenTypeOf(token string) TokenType {
+	switch {
+	case strings.HasPrefix(token, "xoxb-"):
+		return TokenTypeBot
+	case strings.HasPrefix(token, "xoxp-"):
+		return TokenTypeUser
+	case strings.HasPrefix(token, "xapp-"):
+		return TokenTypeApp
+	default:
+		return TokenTypeUnknown
+	}
+}
+
+// PostMessage posts a message to a channel, or into a thread when params.ThreadTS is set,
+// with optional Block Kit blocks, legacy attachments and presentation overrides
+func (c *Client) PostMessage(ctx context.Context, params *PostMessageParameters) (*Message, error) {
+	options := []slack.MsgOption{
+		slack.MsgOptionText(params.Text, false),
+	}
+	if len(params.Blocks) > 0 {
+		options = append(options, slack.MsgOptionBlocks(params.Blocks...))
+	}
+	if len(params.Attachments) > 0 {
+		options = append(options, slack.MsgOptionAttachments(params.Attachments...))
+	}
+	if params.UnfurlLinks != nil {
+		if *params.UnfurlLinks {
+			options = append(options, slack.MsgOptionEnableLinkUnfurl())
+		} else {
+			options = append(options, slack.MsgOptionDisableLinkUnfurl())
+		}
+	}
+	if params.UnfurlMedia != nil && !*params.UnfurlMedia {
+		options = append(options, slack.MsgOptionDisableMediaUnfurl())
+	}
+	if params.Mrkdwn != nil && !*params.Mrkdwn {
+		options = append(options, slack.MsgOptionDisableMarkdown())
+	}
+	if params.Username != "" {
+		options = append(options, slack.MsgOptionUsername(params.Username))
+	}
+	if params.IconEmoji != "" {
+		options = append(options, slack.MsgOptionIconEmoji(":"+strings.Trim(params.IconEmoji, ":")+":"))
+	}
+	if params.ThreadTS != "" {
+		options = append(options, slack.MsgOptionTS(params.ThreadTS))
+		if params.ReplyBroadcast {
+			options = append(options, slack.MsgOptionBroadcast())
+		}
+	}
+
+	_, timestamp, err := c.api.PostMessageContext(ctx, params.ChannelID, options...)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Message{
 		Timestamp:       timestamp,
-		Channel:         channelID,
-		Text:            text,
-		ThreadTimestamp: threadTS,
+		Channel:         params.ChannelID,
+		Text:            params.Text,
+		ThreadTimestamp: params.ThreadTS,
 	}, nil
 }
 
+// PostReply posts a reply to the thread params.ThreadTS, optionally broadcasting it to the
+// channel as well, and looks up the reply's permalink
+func (c *Client) PostReply(ctx context.Context, params *PostMessageParameters) (*Message, error) {
+	if params.ThreadTS == "" {
+		return nil, fmt.Errorf("thread timestamp is required to post a reply")
+	}
+
+	message, err := c.PostMessage(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// the reply is already posted, so a missing permalink is not an error
+	permalink, err := c.api.GetPermalinkContext(ctx, &slack.PermalinkParameters{
+		Channel: params.ChannelID,
+		Ts:      message.Timestamp,
+	})
+	if err == nil {
+		message.Permalink = permalink
+	}
+	return message, nil
+}
+
+// emojiNamePattern matches the characters Slack allows in emoji names
+var emojiNamePattern = regexp.MustCompile(`^[a-z0-9_+\-']+$`)
+
+// skinTonePattern matches the skin-tone modifiers Slack appends to a reaction name
+var skinTonePattern = regexp.MustCompile(`^skin-tone-[2-6]$`)
+
+// NormalizeEmojiName turns user input such as ":thumbsup::skin-tone-2:" into the reaction name
+// Slack stores ("thumbsup::skin-tone-2"). The skin tone is kept: it is part of the reaction's
+// name, and a reaction added with one can only be removed by the same name.
+func NormalizeEmojiName(name string) (string, error) {
+	name = strings.Trim(strings.ToLower(strings.TrimSpace(name)), ":")
+	base, modifier, toned := strings.Cut(name, "::")
+	if !emojiNamePattern.MatchString(base) || (toned && !skinTonePattern.MatchString(modifier)) {
+		return "", fmt.Errorf("invalid emoji name %q", name)
+	}
+	return name, nil
+}
+
 // AddReaction adds a reaction to a message
-func (c *Client) AddReaction(channelID, timestamp, reaction string) error {
-	return c.api.AddReaction(reaction, slack.ItemRef{
+func (c *Client) AddReaction(ctx context.Context, channelID, timestamp, reaction string) error {
+	name, err := NormalizeEmojiName(reaction)
+	if err != nil {
+		return err
+	}
+	return c.api.AddReactionContext(ctx, name, slack.ItemRef{
 		Channel:   channelID,
 		Timestamp: timestamp,
 	})
 }
 
-// GetChannelHistory gets the message history of a channel
-func (c *Client) GetChannelHistory(channelID string, limit int) (*slack.GetConversationHistoryResponse, error) {
-	params := &slack.GetConversationHistoryParameters{
-		ChannelID: channelID,
-		Limit:     limit,
+// RemoveReaction removes a reaction from a message
+func (c *Client) RemoveReaction(ctx context.Context, channelID, timestamp, reaction string) error {
+	name, err := NormalizeEmojiName(reaction)
+	if err != nil {
+		return err
 	}
-	return c.api.GetConversationHistory(params)
+	return c.api.RemoveReactionContext(ctx, name, slack.ItemRef{
+		Channel:   channelID,
+		Timestamp: timestamp,
+	})
 }
 
-// GetThreadReplies gets all replies in a thread
-func (c *Client) GetThreadReplies(threadURL string) (*GetThreadRepliesResponse, error) {
-	// 解析URL获取channelID和timestamp
-	// URL格式: https://workspace.slack.com/archives/C0734812MFG/p1742788004223029
-	parts := strings.Split(threadURL, "/")
-	if len(parts) < 2 {
-		return nil, fmt.Errorf("invalid thread URL format")
+// GetReactions gets all reactions on a message with the users who added them
+func (c *Client) GetReactions(ctx context.Context, channelID, timestamp string) ([]*ReactionInfo, error) {
+	items, err := c.api.GetReactionsContext(ctx, slack.ItemRef{
+		Channel:   channelID,
+		Timestamp: timestamp,
+	}, slack.GetReactionsParameters{Full: true})
+	if err != nil {
+		return nil, err
 	}
 
-	channelID := parts[len(parts)-2]
-	timestampStr := parts[len(parts)-1]
+	reactions := make([]*ReactionInfo, 0, len(items))
+	for _, item := range items {
+		reactions = append(reactions, &ReactionInfo{
+			Name:  item.Name,
+			Count: item.Count,
+			Users: item.Users,
+		})
+	}
+	return reactions, nil
+}
 
-	// 处理timestamp格式
-	// 将格式从 p1742788004223029 转换为 1742788004.223029
-	if !strings.HasPrefix(timestampStr, "p") {
-		return nil, fmt.Errorf("invalid timestamp format in URL")
+// GetChannelHistory gets one page of a channel's message history within an optional time window
+func (c *Client) GetChannelHistory(ctx context.Context, params *GetChannelHistoryParameters) (*GetChannelHistoryResponse, error) {
+	oldest, err := normalizeTimestamp(params.Oldest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid oldest: %w", err)
 	}
-	tsNum := timestampStr[1:] // 去掉p前缀
-	if len(tsNum) != 16 {
-		return nil, fmt.Errorf("invalid timestamp length")
+	latest, err := normalizeTimestamp(params.Latest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latest: %w", err)
 	}
-	threadTS := fmt.Sprintf("%s.%s", tsNum[:10], tsNum[10:])
 
-	params := &slack.GetConversationRepliesParameters{
-		ChannelID: channelID,
-		Timestamp: threadTS,
-	}
-	messages, _, _, err := c.api.GetConversationReplies(params)
+	history, err := c.api.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{
+		ChannelID: params.ChannelID,
+		Cursor:    params.Cursor,
+		Inclusive: params.Inclusive,
+		Latest:    latest,
+		Limit:     params.Limit,
+		Oldest:    oldest,
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &GetThreadRepliesResponse{
-		Messages: messages,
+
+	nextCursor := ""
+	if history.HasMore {
+		nextCursor = history.ResponseMetaData.NextCursor
+	}
+	return &GetChannelHistoryResponse{
+		Messages:   history.Messages,
+		HasMore:    history.HasMore,
+		NextCursor: nextCursor,
 	}, nil
 }
 
-// GetUsers gets a list of all users
-func (c *Client) GetUsers(limit int, cursor string) (*GetUsersResponse, error) {
-	users, err := c.api.GetUsers()
+// normalizeTimestamp converts a time window boundary into a Slack timestamp.
+// It accepts Slack timestamps (1742788004.223029), Unix seconds, RFC 3339 times and plain dates (2006-01-02).
+func normalizeTimestamp(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return formatTimestamp(t), nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return formatTimestamp(t), nil
+	}
+	return "", fmt.Errorf("unrecognized time %q, expected a Slack timestamp, RFC 3339 time or YYYY-MM-DD date", value)
+}
+
+// formatTimestamp formats a time as a Slack timestamp with microsecond precision
+func formatTimestamp(t time.Time) string {
+	return fmt.Sprintf("%d.%06d", t.Unix(), t.Nanosecond()/int(time.Microsecond))
+}
+
+// ThreadRepliesIterator pages through the messages of a thread, one conversations.replies call per page
+type ThreadRepliesIterator struct {
+	api      API
+	params   slack.GetConversationRepliesParameters
+	finished bool
+}
+
+// IterateThreadReplies returns an iterator over the messages of the thread started by threadTS.
+// pageSize is the number of messages requested per call; 0 uses Slack's default.
+func (c *Client) IterateThreadReplies(channelID, threadTS string, pageSize int) *ThreadRepliesIterator {
+	return &ThreadRepliesIterator{
+		api: c.api,
+		params: slack.GetConversationRepliesParameters{
+			ChannelID: channelID,
+			Timestamp: threadTS,
+			Limit:     pageSize,
+		},
+	}
+}
+
+// HasNext reports whether another page can be fetched
+func (it *ThreadRepliesIterator) HasNext() bool {
+	return !it.finished
+}
+
+// Cursor returns the cursor of the next page, or "" when the thread has been fully read
+func (it *ThreadRepliesIterator) Cursor() string {
+	if it.finished {
+		return ""
+	}
+	return it.params.Cursor
+}
+
+// Next fetches the next page of messages
+func (it *ThreadRepliesIterator) Next(ctx context.Context) ([]slack.Message, error) {
+	if it.finished {
+		return nil, fmt.Errorf("no more thread replies")
+	}
+	messages, hasMore, nextCursor, err := it.api.GetConversationRepliesContext(ctx, &it.params)
 	if err != nil {
 		return nil, err
 	}
-	return &GetUsersResponse{
-		Members: users,
-		ResponseMetadata: struct{ NextCursor string }{
-			NextCursor: cursor,
-		},
+	it.params.Cursor = nextCursor
+	it.finished = !hasMore || nextCursor == ""
+	return messages, nil
+}
+
+// GetThreadReplies gets the replies in the thread a Slack message URL points at, following
+// pagination until the thread is exhausted or maxMessages messages have been collected.
+// Reply permalinks resolve to their parent thread. maxMessages <= 0 means no cap.
+func (c *Client) GetThreadReplies(ctx context.Context, threadURL string, maxMessages int) (*GetThreadRepliesResponse, error) {
+	link, err := ParsePermalink(threadURL)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetThread(ctx, link.ChannelID, link.ThreadTS, maxMessages)
+}
+
+// GetThread gets the messages of the thread started by threadTS in a channel, like
+// GetThreadReplies does for a message URL
+func (c *Client) GetThread(ctx context.Context, channelID, threadTS string, maxMessages int) (*GetThreadRepliesResponse, error) {
+	pageSize := threadRepliesPageSize
+	if maxMessages > 0 && maxMessages < pageSize {
+		pageSize = maxMessages
+	}
+
+	it := c.IterateThreadReplies(channelID, threadTS, pageSize)
+	var messages []slack.Message
+	for it.HasNext() {
+		if maxMessages > 0 && len(messages) >= maxMessages {
+			break
+		}
+		page, err := it.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, page...)
+	}
+
+	truncated := it.HasNext()
+	if maxMessages > 0 && len(messages) > maxMessages {
+		messages = messages[:maxMessages]
+		truncated = true
+	}
+	return &GetThreadRepliesResponse{
+		ChannelID: channelID,
+		ThreadTS:  threadTS,
+		Messages:  messages,
+		Truncated: truncated,
 	}, nil
 }
 
+// GetUsers gets one page of the workspace's users from users.list, applying the
+// parameters' filters to that page. Because filtering happens after Slack returns the
+// page, a page may hold fewer than Limit users even when NextCursor is not empty.
+func (c *Client) GetUsers(ctx context.Context, params *GetUsersParameters) (*GetUsersResponse, error) {
+	values := url.Values{
+		"limit":          {strconv.Itoa(params.Limit)},
+		"include_locale": {"true"},
+	}
+	if params.Cursor != "" {
+		values.Set("cursor", params.Cursor)
+	}
+
+	var response struct {
+		slack.SlackResponse
+		Members []slack.User `json:"members"`
+	}
+	if _, err := c.callMethod(ctx, "users.list", values, &response); err != nil {
+		return nil, err
+	}
+
+	members := make([]slack.User, 0, len(response.Members))
+	for _, user := range response.Members {
+		if params.matches(&user) {
+			members = append(members, user)
+		}
+	}
+
+	result := &GetUsersResponse{Members: members}
+	result.ResponseMetadata.NextCursor = response.ResponseMetadata.Cursor
+	return result, nil
+}
+
+// matches reports whether a user passes the filters of a GetUsers call
+func (params *GetUsersParameters) matches(user *slack.User) bool {
+	if user.Deleted && !params.IncludeDeleted {
+		return false
+	}
+	if (user.IsBot || user.ID == "USLACKBOT") && !params.IncludeBots {
+		return false
+	}
+	if (user.IsRestricted || user.IsUltraRestricted) && !params.IncludeGuests {
+		return false
+	}
+	if params.TimeZone != "" && !strings.EqualFold(user.TZ, params.TimeZone) {
+		return false
+	}
+	return true
+}
+
 // GetUserProfile gets a user's profile
-func (c *Client) GetUserProfile(userID string) (*slack.UserProfile, error) {
-	user, err := c.api.GetUserInfo(userID)
+func (c *Client) GetUserProfile(ctx context.Context, userID string) (*slack.UserProfile, error) {
+	user, err := c.api.GetUserInfoContext(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -132,54 +498,67 @@ func (c *Client) GetUserProfile(userID string) (*slack.UserProfile, error) {
 }
 
 // GetFilteredUserProfile gets filtered user profile information
-func (c *Client) GetFilteredUserProfile(userID string) (*UserProfileInfo, error) {
-	user, err := c.api.GetUserInfo(userID)
+func (c *Client) GetFilteredUserProfile(ctx context.Context, userID string) (*UserProfileInfo, error) {
+	user, err := c.api.GetUserInfoContext(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	return &UserProfileInfo{
-		Name:        user.Name,
-		FullName:    user.Profile.RealName,
-		DisplayName: user.Profile.DisplayName,
-		Email:       user.Profile.Email,
-		Title:       user.Profile.Title,
-	}, nil
+	return NewUserProfileInfo(user), nil
 }
 
 // GetFilteredUsersProfile gets filtered user profile information for multiple users
-func (c *Client) GetFilteredUsersProfile(userIDs []string) ([]*UserProfileInfo, error) {
-	users, err := c.api.GetUsersInfo(userIDs...)
+func (c *Client) GetFilteredUsersProfile(ctx context.Context, userIDs []string) ([]*UserProfileInfo, error) {
+	users, err := c.api.GetUsersInfoContext(ctx, userIDs...)
 	if err != nil {
 		return nil, err
 	}
 
 	profiles := make([]*UserProfileInfo, 0, len(*users))
-	for _, user := range *users {
-		profiles = append(profiles, &UserProfileInfo{
-			Name:        user.Name,
-			FullName:    user.Profile.RealName,
-			DisplayName: user.Profile.DisplayName,
-			Email:       user.Profile.Email,
-			Title:       user.Profile.Title,
-		})
+	for i := range *users {
+		profiles = append(profiles, NewUserProfileInfo(&(*users)[i]))
 	}
 
 	return profiles, nil
 }
 
-// ListChannels lists all public channels in the workspace
-func (c *Client) ListChannels(limit int, cursor string) (*GetConversationsResponse, error) {
-	params := &slack.GetConversationsParameters{
-		Limit:           limit,
-		Cursor:          cursor,
-		ExcludeArchived: true,
-		Types:           []string{"public_channel"},
+// NewUserProfileInfo extracts the filtered profile information of a user
+func NewUserProfileInfo(user *slack.User) *UserProfileInfo {
+	return &UserProfileInfo{
+		ID:          user.ID,
+		Name:        user.Name,
+		FullName:    user.Profile.RealName,
+		DisplayName: user.Profile.DisplayName,
+		Email:       user.Profile.Email,
+		Title:       user.Profile.Title,
+		TimeZone:    user.TZ,
 	}
-	channels, nextCursor, err := c.api.GetConversations(params)
+}
+
+// ListChannels lists one page of public channels in the workspace. The name prefix filter
+// applies to the page Slack returns, so a page may hold fewer than Limit channels even when
+// NextCursor is not empty.
+func (c *Client) ListChannels(ctx context.Context, params *ListChannelsParameters) (*GetConversationsResponse, error) {
+	channels, nextCursor, err := c.api.GetConversationsContext(ctx, &slack.GetConversationsParameters{
+		Limit:           params.Limit,
+		Cursor:          params.Cursor,
+		ExcludeArchived: !params.IncludeArchived,
+		Types:           []string{"public_channel"},
+	})
 	if err != nil {
 		return nil, err
 	}
+
+	if prefix := normalizeChannelName(params.NamePrefix); prefix != "" {
+		filtered := channels[:0]
+		for _, channel := range channels {
+			if strings.HasPrefix(strings.ToLower(channel.Name), prefix) {
+				filtered = append(filtered, channel)
+			}
+		}
+		channels = filtered
+	}
+
 	return &GetConversationsResponse{
 		Channels: channels,
 		ResponseMetadata: struct{ NextCursor string }{
@@ -187,3 +566,65 @@ func (c *Client) ListChannels(limit int, cursor string) (*GetConversationsRespon
 		},
 	}, nil
 }
+
+// NewChannelInfo extracts the compact view of a channel
+func NewChannelInfo(channel *slack.Channel) *ChannelInfo {
+	return &ChannelInfo{
+		ID:          channel.ID,
+		Name:        channel.Name,
+		Topic:       channel.Topic.Value,
+		Purpose:     channel.Purpose.Value,
+		MemberCount: channel.NumMembers,
+		IsPrivate:   channel.IsPrivate,
+		IsArchived:  channel.IsArchived,
+		Created:     int64(channel.Created),
+	}
+}
+
+// SearchMessages searches messages across the workspace using search.messages.
+// The query supports Slack search modifiers such as in:#channel, from:@user, before:, after: and has:.
+// Search is only available to user tokens; bot tokens fail fast with ErrUserTokenRequired.
+func (c *Client) SearchMessages(ctx context.Context, params *SearchMessagesParameters) (*SearchMessagesResponse, error) {
+	if c.tokenType == TokenTypeBot {
+		return nil, ErrUserTokenRequired
+	}
+
+	searchParams := slack.NewSearchParameters()
+	if params.Sort != "" {
+		searchParams.Sort = params.Sort
+	}
+	if params.SortDirection != "" {
+		searchParams.SortDirection = params.SortDirection
+	}
+	if params.Count > 0 {
+		searchParams.Count = params.Count
+	}
+	if params.Page > 0 {
+		searchParams.Page = params.Page
+	}
+
+	result, err := c.api.SearchMessagesContext(ctx, params.Query, searchParams)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]*SearchMatch, 0, len(result.Matches))
+	for _, match := range result.Matches {
+		matches = append(matches, &SearchMatch{
+			ChannelID:   match.Channel.ID,
+			ChannelName: match.Channel.Name,
+			User:        match.User,
+			Username:    match.Username,
+			Timestamp:   match.Timestamp,
+			Text:        match.Text,
+			Permalink:   match.Permalink,
+		})
+	}
+	return &SearchMessagesResponse{
+		Query:     params.Query,
+		Total:     result.Total,
+		Page:      result.Paging.Page,
+		PageCount: result.Paging.Pages,
+		Matches:   matches,
+	}, nil
+}
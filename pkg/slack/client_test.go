@@ -0,0 +1,26 @@
+package slack
+
+import "testing"
+
+func TestNormalizeEmojiName(t *testing.T) {
+	for input, want := range map[string]string{
+		"eyes":                    "eyes",
+		":white_check_mark:":      "white_check_mark",
+		"  :Tada:  ":              "tada",
+		"+1":                      "+1",
+		":thumbsup:":              "thumbsup",
+		"simple_smile":            "simple_smile",
+		"man-raising-hand":        "man-raising-hand",
+		":thumbsup::skin-tone-2:": "thumbsup::skin-tone-2",
+		"wave::skin-tone-6":       "wave::skin-tone-6",
+	} {
+		if got, err := NormalizeEmojiName(input); err != nil || got != want {
+			t.Errorf("NormalizeEmojiName(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "::", "  ", "thumbs up", ":thumbsup::skin-tone-7:", "thumbsup::wave", "👍"} {
+		if got, err := NormalizeEmojiName(input); err == nil {
+			t.Errorf("NormalizeEmojiName(%q) = %q, want an error", input, got)
+		}
+	}
+}
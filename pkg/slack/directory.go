@@ -0,0 +1,294 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	// userDirectoryTTL is how long the cached users.list snapshot is reused
+	userDirectoryTTL = 15 * time.Minute
+	// minUserMatchConfidence drops candidates that are too far from the query
+	minUserMatchConfidence = 0.4
+	// resolveUserConfidence is the confidence a lone candidate needs to be used in place of a user ID
+	resolveUserConfidence = 0.9
+)
+
+var (
+	// userIDPattern matches user IDs: regular (U) and Enterprise Grid (W) users
+	userIDPattern = regexp.MustCompile(`^[UW][A-Z0-9]{6,}$`)
+	// emailPattern is a loose check that a query is meant as an email address
+	emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
+// userDirectory caches the workspace's users for name lookups
+type userDirectory struct {
+	mu       sync.Mutex
+	users    []slack.User
+	loadedAt time.Time
+}
+
+// cachedUsers returns every active user in the workspace, reloading the snapshot once it expires
+func (c *Client) cachedUsers(ctx context.Context) ([]slack.User, error) {
+	c.directory.mu.Lock()
+	defer c.directory.mu.Unlock()
+
+	if c.directory.users != nil && time.Since(c.directory.loadedAt) < userDirectoryTTL {
+		return c.directory.users, nil
+	}
+
+	params := &GetUsersParameters{
+		Limit:         200,
+		IncludeBots:   true,
+		IncludeGuests: true,
+	}
+	var users []slack.User
+	for {
+		page, err := c.GetUsers(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load user directory: %w", err)
+		}
+		users = append(users, page.Members...)
+		if page.ResponseMetadata.NextCursor == "" {
+			break
+		}
+		params.Cursor = page.ResponseMetadata.NextCursor
+	}
+
+	c.directory.users = users
+	c.directory.loadedAt = time.Now()
+	return users, nil
+}
+
+// FindUsers looks up users by ID, email, @handle, display name or real name and returns
+// up to limit candidates ranked by confidence. Emails are resolved with users.lookupByEmail;
+// everything else is fuzzy-matched against a cached users.list snapshot.
+func (c *Client) FindUsers(ctx context.Context, query string, limit int) ([]*UserCandidate, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+
+	if userIDPattern.MatchString(query) {
+		user, err := c.api.GetUserInfoContext(ctx, query)
+		if err == nil {
+			return []*UserCandidate{newUserCandidate(user, 1, "id")}, nil
+		}
+		if !isUserNotFound(err) {
+			return nil, err
+		}
+		// a name typed in capitals, such as WILLIAMS, looks like an ID too
+		candidates, nameErr := c.matchUsers(ctx, query, limit)
+		if nameErr != nil || len(candidates) == 0 {
+			return nil, err
+		}
+		return candidates, nil
+	}
+
+	if emailPattern.MatchString(query) {
+		user, err := c.api.GetUserByEmailContext(ctx, query)
+		if err == nil {
+			return []*UserCandidate{newUserCandidate(user, 1, "email")}, nil
+		}
+		// fall back to the emails visible in users.list, e.g. when users:read.email is missing
+		var slackErr slack.SlackErrorResponse
+		if !errors.As(err, &slackErr) || slackErr.Err != "users_not_found" {
+			return nil, err
+		}
+	}
+	return c.matchUsers(ctx, query, limit)
+}
+
+// matchUsers fuzzy-matches a query against the cached users.list snapshot
+func (c *Client) matchUsers(ctx context.Context, query string, limit int) ([]*UserCandidate, error) {
+	users, err := c.cachedUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*UserCandidate
+	for i := range users {
+		if confidence, field := scoreUser(&users[i], query); confidence >= minUserMatchConfidence {
+			candidates = append(candidates, newUserCandidate(&users[i], confidence, field))
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+// ResolveUserID turns a user ID, email, @handle or name into a user ID.
+// Anything other than an ID must match a single user with high confidence. IDs already in
+// the cached directory pass through without an API call; other IDs are checked with
+// users.info, since a name typed in capitals (WILLIAMS) has the shape of an ID.
+func (c *Client) ResolveUserID(ctx context.Context, identifier string) (string, error) {
+	identifier = strings.TrimSpace(identifier)
+	var candidates []*UserCandidate
+	var err error
+	if userIDPattern.MatchString(identifier) {
+		if c.knownUserID(identifier) {
+			return identifier, nil
+		}
+		// without users:read the ID cannot be checked, so it is used as given
+		_, infoErr := c.api.GetUserInfoContext(ctx, identifier)
+		if !isUserNotFound(infoErr) {
+			return identifier, nil
+		}
+		if candidates, err = c.matchUsers(ctx, identifier, 3); err == nil && len(candidates) == 0 {
+			return "", infoErr
+		}
+	} else {
+		candidates, err = c.FindUsers(ctx, identifier, 3)
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no user matches %q", identifier)
+	}
+	best := candidates[0]
+	if best.Confidence < resolveUserConfidence ||
+		(len(candidates) > 1 && candidates[1].Confidence >= best.Confidence) {
+		names := make([]string, 0, len(candidates))
+		for _, candidate := range candidates {
+			names = append(names, fmt.Sprintf("%s (%s, %.2f)", candidate.Name, candidate.ID, candidate.Confidence))
+		}
+		return "", fmt.Errorf("%q is ambiguous, candidates: %s", identifier, strings.Join(names, ", "))
+	}
+	return best.ID, nil
+}
+
+// knownUserID reports whether the cached users.list snapshot holds the user
+func (c *Client) knownUserID(userID string) bool {
+	c.directory.mu.Lock()
+	defer c.directory.mu.Unlock()
+	for i := range c.directory.users {
+		if c.directory.users[i].ID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// isUserNotFound reports whether Slack answered that no user has the ID
+func isUserNotFound(err error) bool {
+	var slackErr slack.SlackErrorResponse
+	return errors.As(err, &slackErr) && slackErr.Err == "user_not_found"
+}
+
+// newUserCandidate builds a ranked lookup result
+func newUserCandidate(user *slack.User, confidence float64, matchedOn string) *UserCandidate {
+	return &UserCandidate{
+		UserProfileInfo: *NewUserProfileInfo(user),
+		Confidence:      confidence,
+		MatchedOn:       matchedOn,
+	}
+}
+
+// scoreUser rates how well a user matches a free-text query and names the field that matched best
+func scoreUser(user *slack.User, query string) (float64, string) {
+	q := normalizeName(query)
+	fields := []struct {
+		name   string
+		value  string
+		weight float64
+	}{
+		{"name", user.Name, 1},
+		{"display_name", user.Profile.DisplayName, 0.98},
+		{"real_name", user.Profile.RealName, 0.95},
+		{"email", user.Profile.Email, 1},
+	}
+
+	best, bestField := 0.0, ""
+	for _, field := range fields {
+		value := normalizeName(field.value)
+		if value == "" {
+			continue
+		}
+		if field.name == "email" {
+			// only an exact email is meaningful; partial emails match the handle instead
+			if value == q {
+				return 1, field.name
+			}
+			continue
+		}
+
+		var score float64
+		switch {
+		case value == q:
+			score = 1
+		case strings.HasPrefix(value, q):
+			score = 0.8
+		case containsWordPrefix(value, q):
+			score = 0.7
+		case strings.Contains(value, q):
+			score = 0.6
+		default:
+			score = 0.7 * similarity(value, q)
+		}
+		if score *= field.weight; score > best {
+			best, bestField = score, field.name
+		}
+	}
+	return best, bestField
+}
+
+// normalizeName lowercases a name and strips the decorations people type around handles
+func normalizeName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.TrimPrefix(name, "@")
+}
+
+// containsWordPrefix reports whether any word of value starts with prefix (e.g. "smith" in "jane smith")
+func containsWordPrefix(value, prefix string) bool {
+	for _, word := range strings.FieldsFunc(value, func(r rune) bool {
+		return r == ' ' || r == '.' || r == '_' || r == '-'
+	}) {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// similarity is 1 minus the Levenshtein distance of a and b relative to the longer string
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
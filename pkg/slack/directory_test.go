@@ -0,0 +1,158 @@
+package slack
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestScoreUser(t *testing.T) {
+	user := &slack.User{
+		Name:    "jsmith",
+		Profile: slack.UserProfile{DisplayName: "Jane S", RealName: "Jane Smith", Email: "jane@example.com"},
+	}
+	for query, want := range map[string]struct {
+		confidence float64
+		field      string
+	}{
+		"jsmith":           {1, "name"},
+		"  @JSmith ":       {1, "name"},
+		"JANE@example.com": {1, "email"},
+		"jane s":           {0.98, "display_name"},
+		"Jane Smith":       {0.95, "real_name"},
+		// a prefix of both names counts for the display name, which people see in the client
+		"jane": {0.8 * 0.98, "display_name"},
+		// a later word of the real name beats a substring of the handle
+		"smith": {0.7 * 0.95, "real_name"},
+		"jsm":   {0.8, "name"},
+		"mit":   {0.6, "name"},
+	} {
+		confidence, field := scoreUser(user, query)
+		if math.Abs(confidence-want.confidence) > 1e-9 || field != want.field {
+			t.Errorf("scoreUser(%q) = %.3f on %s, want %.3f on %s", query, confidence, field, want.confidence, want.field)
+		}
+	}
+
+	// partial emails and unrelated queries stay under the match threshold
+	for _, query := range []string{"example.com", "xyz"} {
+		if confidence, field := scoreUser(user, query); confidence >= minUserMatchConfidence {
+			t.Errorf("scoreUser(%q) = %.3f on %s, want under %.1f", query, confidence, field, minUserMatchConfidence)
+		}
+	}
+}
+
+func TestGetUsersParametersMatches(t *testing.T) {
+	users := map[string]*slack.User{
+		"member":   {ID: "U0MEMBER01", TZ: "Asia/Singapore"},
+		"deleted":  {ID: "U0GONE0001", Deleted: true},
+		"bot":      {ID: "U0BOT00001", IsBot: true},
+		"slackbot": {ID: "USLACKBOT"},
+		"guest":    {ID: "U0GUEST001", IsRestricted: true},
+	}
+	for name, tc := range map[string]struct {
+		params GetUsersParameters
+		want   string
+	}{
+		"defaults":        {GetUsersParameters{}, "member"},
+		"deleted":         {GetUsersParameters{IncludeDeleted: true}, "deleted member"},
+		"bots":            {GetUsersParameters{IncludeBots: true}, "bot member slackbot"},
+		"guests":          {GetUsersParameters{IncludeGuests: true}, "guest member"},
+		"time zone":       {GetUsersParameters{IncludeBots: true, TimeZone: "asia/singapore"}, "member"},
+		"other time zone": {GetUsersParameters{TimeZone: "Europe/London"}, ""},
+	} {
+		var kept []string
+		for _, user := range []string{"bot", "deleted", "guest", "member", "slackbot"} {
+			if tc.params.matches(users[user]) {
+				kept = append(kept, user)
+			}
+		}
+		if got := strings.Join(kept, " "); got != tc.want {
+			t.Errorf("%s: kept %q, want %q", name, got, tc.want)
+		}
+	}
+}
+
+func TestFindUsers(t *testing.T) {
+	client, fake := newTestClient(t)
+	for _, user := range []slack.User{
+		{ID: "U0ALICE001", Name: "alice", Profile: slack.UserProfile{DisplayName: "Ali", RealName: "Alice Smith"}},
+		{ID: "U0ALICE002", Name: "alice.w", Profile: slack.UserProfile{RealName: "Alice Wong"}},
+		{ID: "U0BOB00001", Name: "bob", Deleted: true, Profile: slack.UserProfile{RealName: "Bob Jones"}},
+		{ID: "U0DEPLOY01", Name: "deploybot", IsBot: true, Profile: slack.UserProfile{RealName: "Deploy Bot"}},
+		{ID: "U0WILL0001", Name: "williams", Profile: slack.UserProfile{RealName: "Sam Williams"}},
+		{ID: "U0UMBERT01", Name: "umberto", Profile: slack.UserProfile{RealName: "Umberto Eco"}},
+	} {
+		fake.AddUser(user)
+	}
+	ctx := context.Background()
+
+	for query, want := range map[string]string{
+		"alice":      "U0ALICE001 U0ALICE002",
+		"ALICE":      "U0ALICE001 U0ALICE002",
+		"wong":       "U0ALICE002",
+		"Ali":        "U0ALICE001 U0ALICE002",
+		"deploy bot": "U0DEPLOY01",
+		"bob":        "",
+		// names typed in capitals have the shape of an ID
+		"WILLIAMS": "U0WILL0001",
+		"UMBERTO":  "U0UMBERT01",
+	} {
+		candidates, err := client.FindUsers(ctx, query, 5)
+		if err != nil {
+			t.Errorf("FindUsers(%q): %v", query, err)
+			continue
+		}
+		var ids []string
+		for _, candidate := range candidates {
+			ids = append(ids, candidate.ID)
+		}
+		if got := strings.Join(ids, " "); got != want {
+			t.Errorf("FindUsers(%q) = %s, want %s", query, got, want)
+		}
+	}
+
+	for identifier, want := range map[string]string{
+		"alice":      "U0ALICE001",
+		"@alice.w":   "U0ALICE002",
+		"ali":        "U0ALICE001",
+		"Alice Wong": "U0ALICE002",
+		"U0BOB00001": "U0BOB00001",
+		"WILLIAMS":   "U0WILL0001",
+		"UMBERTO":    "U0UMBERT01",
+	} {
+		if got, err := client.ResolveUserID(ctx, identifier); err != nil || got != want {
+			t.Errorf("ResolveUserID(%q) = %q, %v; want %s", identifier, got, err, want)
+		}
+	}
+	// deactivated users are not matched by name, and prefixes are too weak to act on
+	for identifier, message := range map[string]string{
+		"bob":      "no user matches",
+		"alice w":  "ambiguous",
+		"al":       "ambiguous",
+		"nobody-x": "no user matches",
+	} {
+		if got, err := client.ResolveUserID(ctx, identifier); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("ResolveUserID(%q) = %q, %v; want an error saying %s", identifier, got, err, message)
+		}
+	}
+	for _, identifier := range []string{"U0NOBODY01", "WALDORF"} {
+		if _, err := client.FindUsers(ctx, identifier, 5); DescribeError(err).Code != "user_not_found" {
+			t.Errorf("FindUsers(%q) = %v, want user_not_found", identifier, err)
+		}
+		if got, err := client.ResolveUserID(ctx, identifier); DescribeError(err).Code != "user_not_found" {
+			t.Errorf("ResolveUserID(%q) = %q, %v; want user_not_found", identifier, got, err)
+		}
+	}
+
+	// IDs in the cached directory are not checked again
+	calls := len(fake.Calls("users.info"))
+	if got, err := client.ResolveUserID(ctx, "U0ALICE002"); err != nil || got != "U0ALICE002" {
+		t.Errorf("ResolveUserID(U0ALICE002) = %q, %v", got, err)
+	}
+	if n := len(fake.Calls("users.info")) - calls; n != 0 {
+		t.Errorf("cached ID took %d users.info calls, want none", n)
+	}
+}
@@ -0,0 +1,151 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// MissingScopeError is a missing_scope failure with the scopes involved
+type MissingScopeError struct {
+	// Needed are the scopes the call needs; any one of them is enough
+	Needed []string
+	// Provided are the scopes the token was granted
+	Provided []string
+}
+
+// Error returns the Slack error code and the scopes the call needs
+func (e *MissingScopeError) Error() string {
+	if len(e.Needed) == 0 {
+		return "missing_scope"
+	}
+	return fmt.Sprintf("missing_scope (needed: %s)", strings.Join(e.Needed, " or "))
+}
+
+// Unwrap lets callers match the error as a slack.SlackErrorResponse
+func (e *MissingScopeError) Unwrap() error {
+	return slack.SlackErrorResponse{Err: "missing_scope"}
+}
+
+// ErrorDetails describes a failed call in terms an agent can act on
+type ErrorDetails struct {
+	// Code is Slack's error code (e.g. not_in_channel) or one of ratelimited, timeout,
+	// network_error, http_error, invalid_argument and request_failed
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Retryable is set when the same call may succeed later, after RetryAfterSeconds if given
+	Retryable         bool     `json:"retryable"`
+	RetryAfterSeconds int      `json:"retry_after_seconds,omitempty"`
+	NeededScopes      []string `json:"needed_scopes,omitempty"`
+	ProvidedScopes    []string `json:"provided_scopes,omitempty"`
+	// Details are the extra messages Slack attached to the error, e.g. which block is invalid
+	Details []string `json:"details,omitempty"`
+}
+
+// slackErrorHints are the actionable explanations of the Slack error codes agents run into most
+var slackErrorHints = map[string]string{
+	"channel_not_found":      "the channel does not exist or is not visible to this token; private channels need the app invited. Look the channel up with slack_find_channel",
+	"not_in_channel":         "the app is not a member of the channel; join it (conversations.join, public channels only) or invite the app with /invite, then retry",
+	"is_archived":            "the channel is archived; unarchive it or use another channel",
+	"missing_scope":          "the token lacks a scope this call needs; add it in the app's OAuth & Permissions settings and reinstall the app",
+	"not_allowed_token_type": "this method does not accept this kind of token (e.g. search needs a user token, xoxp-)",
+	"invalid_auth":           "the token is invalid; replace SLACK_TOKEN and restart the server",
+	"not_authed":             "no token was sent; set SLACK_TOKEN and restart the server",
+	"token_revoked":          "the token has been revoked; reinstall the app and replace SLACK_TOKEN",
+	"token_expired":          "the token has expired; refresh it and replace SLACK_TOKEN",
+	"account_inactive":       "the token belongs to a deactivated user or uninstalled app; reinstall the app and replace SLACK_TOKEN",
+	"ratelimited":            "Slack is rate limiting this method; wait and retry",
+	"thread_not_found":       "no thread has this timestamp in the channel; check the thread_ts or URL",
+	"message_not_found":      "no message has this timestamp in the channel; check the timestamp or URL",
+	"user_not_found":         "no user has this ID; look the user up with slack_find_user",
+	"users_not_found":        "no user has this email address; look the user up with slack_find_user",
+	"already_reacted":        "the app already added this reaction to the message",
+	"no_reaction":            "the app has not added this reaction to the message",
+	"invalid_name":           "the emoji name is not valid",
+	"too_many_reactions":     "the message has reached the reaction limit",
+	"msg_too_long":           "the message text is too long; shorten it or split it into several messages",
+	"no_text":                "the message has no text; give text, blocks or attachments",
+	"invalid_blocks":         "Slack rejected the blocks; see details for the offending block",
+	"invalid_blocks_format":  "the blocks are not a valid JSON array of block objects",
+	"restricted_action":      "a workspace preference prevents the app from doing this in this channel",
+	"cant_update_message":    "only messages posted by this app can be changed",
+	"time_in_past":           "the post time is in the past; schedule the message for a later time",
+	"time_too_far":           "the post time is more than 120 days ahead; Slack cannot schedule messages that far out",
+	"invalid_time":           "the post time is not a valid Unix timestamp",
+
+	"invalid_scheduled_message_id": "no scheduled message has this ID in the channel, or it was already posted; list them with slack_list_scheduled_messages",
+}
+
+// retryableSlackErrors are Slack error codes worth retrying unchanged
+var retryableSlackErrors = map[string]bool{
+	"ratelimited":         true,
+	"internal_error":      true,
+	"fatal_error":         true,
+	"service_unavailable": true,
+	"request_timeout":     true,
+}
+
+// DescribeError classifies an error returned by a Client method
+func DescribeError(err error) *ErrorDetails {
+	var (
+		rateLimited  *slack.RateLimitedError
+		missingScope *MissingScopeError
+		slackErr     slack.SlackErrorResponse
+		statusErr    slack.StatusCodeError
+		netErr       net.Error
+	)
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &rateLimited):
+		return &ErrorDetails{
+			Code:              "ratelimited",
+			Message:           fmt.Sprintf("%s; retry after %s", slackErrorHints["ratelimited"], rateLimited.RetryAfter),
+			Retryable:         true,
+			RetryAfterSeconds: int(rateLimited.RetryAfter.Seconds() + 0.5),
+		}
+	case errors.As(err, &missingScope):
+		message := slackErrorHints["missing_scope"]
+		if len(missingScope.Needed) > 0 {
+			message = fmt.Sprintf("the token lacks the %s scope; add it in the app's OAuth & Permissions settings and reinstall the app",
+				strings.Join(missingScope.Needed, " or "))
+		}
+		return &ErrorDetails{
+			Code:           "missing_scope",
+			Message:        message,
+			NeededScopes:   missingScope.Needed,
+			ProvidedScopes: missingScope.Provided,
+		}
+	case errors.Is(err, ErrUserTokenRequired):
+		return &ErrorDetails{Code: "not_allowed_token_type", Message: err.Error()}
+	case errors.As(err, &slackErr):
+		details := &ErrorDetails{
+			Code:      slackErr.Err,
+			Message:   slackErrorHints[slackErr.Err],
+			Retryable: retryableSlackErrors[slackErr.Err],
+			Details:   slackErr.ResponseMetadata.Messages,
+		}
+		if details.Message == "" {
+			details.Message = fmt.Sprintf("Slack returned %s", slackErr.Err)
+		}
+		return details
+	case errors.Is(err, context.DeadlineExceeded):
+		return &ErrorDetails{Code: "timeout", Message: "the Slack call did not finish in time; retry, possibly with a smaller limit", Retryable: true}
+	case errors.Is(err, context.Canceled):
+		return &ErrorDetails{Code: "canceled", Message: "the call was canceled"}
+	case errors.As(err, &statusErr):
+		return &ErrorDetails{
+			Code:      "http_error",
+			Message:   fmt.Sprintf("Slack answered with HTTP %s", statusErr.Status),
+			Retryable: statusErr.Code >= 500,
+		}
+	case errors.As(err, &netErr):
+		return &ErrorDetails{Code: "network_error", Message: fmt.Sprintf("could not reach Slack: %v", err), Retryable: true}
+	default:
+		return &ErrorDetails{Code: "request_failed", Message: err.Error()}
+	}
+}
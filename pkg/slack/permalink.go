@@ -0,0 +1,105 @@
+package slack
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// channelIDPattern matches conversation IDs: public (C), private/MPIM (G) and direct message (D) channels
+	channelIDPattern = regexp.MustCompile(`^[CGD][A-Z0-9]{6,}$`)
+	// slackTimestampPattern matches a Slack message timestamp such as 1742788004.223029
+	slackTimestampPattern = regexp.MustCompile(`^\d{10}\.\d{6}$`)
+)
+
+// Permalink is a Slack message URL broken down into the parts the API needs
+type Permalink struct {
+	// ChannelID is the conversation the message was posted in
+	ChannelID string
+	// MessageTS is the timestamp of the message the URL points at
+	MessageTS string
+	// ThreadTS is the timestamp of the thread's parent message.
+	// It equals MessageTS unless the URL points at a reply inside a thread.
+	ThreadTS string
+}
+
+// IsReply reports whether the permalink points at a reply rather than the thread's parent message
+func (p *Permalink) IsReply() bool {
+	return p.ThreadTS != p.MessageTS
+}
+
+// ParsePermalink parses a Slack message URL. Supported formats:
+//
+//	https://workspace.slack.com/archives/C0123ABCDEF/p1742788004223029
+//	https://workspace.slack.com/archives/C0123ABCDEF/p1742788004223029?thread_ts=1742788000.000100&cid=C0123ABCDEF
+//	https://app.slack.com/client/T0123ABCDEF/C0123ABCDEF/thread/C0123ABCDEF-1742788004.223029
+//
+// For reply permalinks (those carrying a thread_ts query parameter) ThreadTS is the parent message.
+func ParsePermalink(rawURL string) (*Permalink, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid message URL: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid message URL %q: missing host", rawURL)
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	var link *Permalink
+	switch {
+	case len(segments) >= 3 && segments[0] == "archives":
+		link, err = parseArchivesPath(segments[1], segments[2])
+	case len(segments) >= 5 && segments[0] == "client" && segments[3] == "thread":
+		link, err = parseClientThreadPath(segments[4])
+	default:
+		return nil, fmt.Errorf("invalid message URL %q: expected /archives/{channel_id}/p{timestamp}", rawURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := u.Query()
+	if threadTS := query.Get("thread_ts"); threadTS != "" {
+		if !slackTimestampPattern.MatchString(threadTS) {
+			return nil, fmt.Errorf("invalid thread_ts %q in message URL", threadTS)
+		}
+		link.ThreadTS = threadTS
+	}
+	if cid := query.Get("cid"); cid != "" && cid != link.ChannelID {
+		return nil, fmt.Errorf("message URL channel %s does not match cid %s", link.ChannelID, cid)
+	}
+	return link, nil
+}
+
+// parseArchivesPath parses the {channel_id}/p{timestamp} part of an /archives/ URL
+func parseArchivesPath(channelID, messageID string) (*Permalink, error) {
+	if !channelIDPattern.MatchString(channelID) {
+		return nil, fmt.Errorf("invalid channel ID %q in message URL", channelID)
+	}
+
+	// 将格式从 p1742788004223029 转换为 1742788004.223029
+	if !strings.HasPrefix(messageID, "p") {
+		return nil, fmt.Errorf("invalid timestamp format in URL")
+	}
+	tsNum := messageID[1:]
+	if len(tsNum) != 16 {
+		return nil, fmt.Errorf("invalid timestamp length")
+	}
+	ts := fmt.Sprintf("%s.%s", tsNum[:10], tsNum[10:])
+	if !slackTimestampPattern.MatchString(ts) {
+		return nil, fmt.Errorf("invalid timestamp %q in message URL", messageID)
+	}
+
+	return &Permalink{ChannelID: channelID, MessageTS: ts, ThreadTS: ts}, nil
+}
+
+// parseClientThreadPath parses the {channel_id}-{thread_ts} part of an app.slack.com thread URL
+func parseClientThreadPath(thread string) (*Permalink, error) {
+	channelID, ts, ok := strings.Cut(thread, "-")
+	if !ok || !channelIDPattern.MatchString(channelID) || !slackTimestampPattern.MatchString(ts) {
+		return nil, fmt.Errorf("invalid thread %q in message URL", thread)
+	}
+	return &Permalink{ChannelID: channelID, MessageTS: ts, ThreadTS: ts}, nil
+}
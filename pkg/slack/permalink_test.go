@@ -0,0 +1,56 @@
+package slack
+
+import "testing"
+
+func TestParsePermalink(t *testing.T) {
+	for rawURL, want := range map[string]Permalink{
+		"https://example.slack.com/archives/C0123ABCDEF/p1742788004223029":   {"C0123ABCDEF", "1742788004.223029", "1742788004.223029"},
+		"https://example.slack.com/archives/C0123ABCDEF/p1742788004223029/":  {"C0123ABCDEF", "1742788004.223029", "1742788004.223029"},
+		"  https://example.slack.com/archives/G0123ABCDEF/p1742788004223029": {"G0123ABCDEF", "1742788004.223029", "1742788004.223029"},
+		"https://example.slack.com/archives/C0123ABCDEF/p1742788004223029?thread_ts=1742788000.000100&cid=C0123ABCDEF": {
+			"C0123ABCDEF", "1742788004.223029", "1742788000.000100",
+		},
+		"https://example.slack.com/archives/D0123ABCDEF/p1742788004223029?thread_ts=1742788000.000100": {
+			"D0123ABCDEF", "1742788004.223029", "1742788000.000100",
+		},
+		"https://app.slack.com/client/T0123ABCDEF/C0123ABCDEF/thread/C0123ABCDEF-1742788004.223029": {
+			"C0123ABCDEF", "1742788004.223029", "1742788004.223029",
+		},
+		"https://app.slack.com/client/T0123ABCDEF/C0123ABCDEF/thread/C0123ABCDEF-1742788004.223029/": {
+			"C0123ABCDEF", "1742788004.223029", "1742788004.223029",
+		},
+	} {
+		link, err := ParsePermalink(rawURL)
+		if err != nil {
+			t.Errorf("ParsePermalink(%q): %v", rawURL, err)
+			continue
+		}
+		if *link != want {
+			t.Errorf("ParsePermalink(%q) = %+v, want %+v", rawURL, *link, want)
+		}
+		if link.IsReply() != (want.ThreadTS != want.MessageTS) {
+			t.Errorf("ParsePermalink(%q).IsReply() = %v", rawURL, link.IsReply())
+		}
+	}
+
+	for name, rawURL := range map[string]string{
+		"no host":           "/archives/C0123ABCDEF/p1742788004223029",
+		"no scheme":         "example.slack.com/archives/C0123ABCDEF/p1742788004223029",
+		"empty":             "",
+		"not a message":     "https://example.slack.com/archives/C0123ABCDEF",
+		"other path":        "https://example.slack.com/messages/C0123ABCDEF/p1742788004223029",
+		"no p":              "https://example.slack.com/archives/C0123ABCDEF/1742788004223029",
+		"short p":           "https://example.slack.com/archives/C0123ABCDEF/p174278800422302",
+		"long p":            "https://example.slack.com/archives/C0123ABCDEF/p17427880042230290",
+		"non-digit p":       "https://example.slack.com/archives/C0123ABCDEF/p17427880042230x9",
+		"bad channel":       "https://example.slack.com/archives/general/p1742788004223029",
+		"bad thread_ts":     "https://example.slack.com/archives/C0123ABCDEF/p1742788004223029?thread_ts=1742788000",
+		"cid mismatch":      "https://example.slack.com/archives/C0123ABCDEF/p1742788004223029?thread_ts=1742788000.000100&cid=C0999ZZZZZZ",
+		"bad client thread": "https://app.slack.com/client/T0123ABCDEF/C0123ABCDEF/thread/C0123ABCDEF:1742788004.223029",
+		"client cid":        "https://app.slack.com/client/T0123ABCDEF/C0123ABCDEF/thread/C0123ABCDEF-1742788004.223029?cid=C0999ZZZZZZ",
+	} {
+		if link, err := ParsePermalink(rawURL); err == nil {
+			t.Errorf("%s: ParsePermalink(%q) = %+v, want an error", name, rawURL, *link)
+		}
+	}
+}
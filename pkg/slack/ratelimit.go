@@ -0,0 +1,342 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxReadRetries is how many times an idempotent read is retried after a 429, a 5xx or a network error
+	maxReadRetries = 3
+	// maxRetryAfter is the longest Retry-After a read waits out; longer limits are returned to the caller
+	maxRetryAfter = time.Minute
+	// retryBackoff is the base of the exponential backoff between retries of failed reads
+	retryBackoff = 500 * time.Millisecond
+	// retryJitter bounds the random delay added to retries so queued calls do not wake up together
+	retryJitter = 500 * time.Millisecond
+	// maxPeekBytes bounds how much of a request body channelOf reads
+	maxPeekBytes = 1 << 20
+)
+
+// rateTier is a Slack Web API rate limit: a sustained rate with room for short bursts
+type rateTier struct {
+	perMinute float64
+	burst     float64
+}
+
+// interval is the time one call uses up at the sustained rate
+func (t rateTier) interval() time.Duration {
+	return time.Duration(float64(time.Minute) / t.perMinute)
+}
+
+// The documented Slack tiers (https://api.slack.com/apis/rate-limits). Slack allows
+// occasional bursts above these rates; the burst sizes here stay on the safe side.
+var (
+	tier2 = rateTier{perMinute: 20, burst: 3}
+	tier3 = rateTier{perMinute: 50, burst: 5}
+	tier4 = rateTier{perMinute: 100, burst: 10}
+	// postMessageTier is chat.postMessage's special limit of one message per second per channel
+	postMessageTier = rateTier{perMinute: 60, burst: 1}
+)
+
+// methodLimit is the tier of a Web API method and whether calling it twice is harmless
+type methodLimit struct {
+	tier rateTier
+	read bool
+}
+
+// methodLimits covers the methods the client calls; others are treated as Tier 3 writes
+var methodLimits = map[string]methodLimit{
+	"auth.test":                   {tier4, true},
+	"chat.getPermalink":           {tier4, true},
+	"chat.postMessage":            {postMessageTier, false},
+	"chat.scheduleMessage":        {tier3, false},
+	"chat.scheduledMessages.list": {tier3, true},
+	"chat.deleteScheduledMessage": {tier3, false},
+	"conversations.history":       {tier3, true},
+	"conversations.info":          {tier3, true},
+	"conversations.join":          {tier3, false},
+	"conversations.list":          {tier2, true},
+	"conversations.replies":       {tier3, true},
+	"reactions.add":               {tier3, false},
+	"reactions.get":               {tier3, true},
+	"reactions.remove":            {tier2, false},
+	"search.messages":             {tier2, true},
+	"team.info":                   {tier3, true},
+	"usergroups.list":             {tier2, true},
+	"users.info":                  {tier4, true},
+	"users.list":                  {tier2, true},
+	"users.lookupByEmail":         {tier3, true},
+	"users.profile.get":           {tier4, true},
+}
+
+// limitOf returns the rate limit of a Web API method
+func limitOf(method string) methodLimit {
+	if limit, ok := methodLimits[method]; ok {
+		return limit
+	}
+	return methodLimit{tier: tier3}
+}
+
+// bucket paces the calls of one method, or of chat.postMessage to one channel, with the
+// generic cell rate algorithm: tat is the time at which the bucket would be empty again
+type bucket struct {
+	tier  rateTier
+	tat   time.Time
+	stats RateLimitStats
+}
+
+// reserve takes a slot and returns how long the caller must wait before using it
+func (b *bucket) reserve(now time.Time) time.Duration {
+	interval := b.tier.interval()
+	tolerance := time.Duration(b.tier.burst-1) * interval
+	start := b.tat
+	if start.Before(now) {
+		start = now
+	}
+	b.tat = start.Add(interval)
+	return max(start.Sub(now)-tolerance, 0)
+}
+
+// release hands back the latest slot of a caller that gave up waiting
+func (b *bucket) release(tat time.Time) {
+	if b.tat.Equal(tat) {
+		b.tat = b.tat.Add(-b.tier.interval())
+	}
+}
+
+// block holds every call of the bucket until until, as asked by a Retry-After header
+func (b *bucket) block(until time.Time) {
+	tolerance := time.Duration(b.tier.burst-1) * b.tier.interval()
+	if tat := until.Add(tolerance); tat.After(b.tat) {
+		b.tat = tat
+	}
+}
+
+// scheduler is an http.RoundTripper that keeps a token's Web API calls within Slack's rate
+// limits. Calls over a method's limit queue until a slot frees up or their context ends;
+// a 429 holds the method until its Retry-After has passed, and idempotent reads are retried
+// after 429s, 5xx responses and network errors with jittered exponential backoff.
+// A Client has one token, so its scheduler's limits are that token's limits.
+type scheduler struct {
+	base    http.RoundTripper
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// newScheduler returns a scheduler sending requests through base
+func newScheduler(base http.RoundTripper) *scheduler {
+	return &scheduler{
+		base:    base,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (s *scheduler) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	method := path.Base(req.URL.Path)
+	limit := limitOf(method)
+	key := method
+	if method == "chat.postMessage" {
+		if channel := channelOf(req); channel != "" {
+			key += " " + channel
+		}
+	}
+	b := s.bucket(key, limit.tier)
+
+	for attempt := 0; ; attempt++ {
+		if err := s.wait(ctx, b, method); err != nil {
+			return nil, err
+		}
+
+		attemptReq := req
+		if attempt > 0 {
+			var err error
+			if attemptReq, err = rewind(req); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := s.base.RoundTrip(attemptReq)
+
+		canRetry := limit.read && attempt < maxReadRetries && (req.Body == nil || req.GetBody != nil) && ctx.Err() == nil
+		var delay time.Duration
+		switch {
+		case err != nil:
+			if !canRetry {
+				return nil, err
+			}
+			delay = backoff(attempt)
+		case resp.StatusCode == http.StatusTooManyRequests:
+			retryAfter := retryAfterOf(resp)
+			s.mu.Lock()
+			b.block(time.Now().Add(retryAfter))
+			b.stats.RateLimited++
+			s.mu.Unlock()
+			log.Printf("slack rate limited %s, retry after %s", key, retryAfter)
+			if !canRetry || retryAfter > maxRetryAfter {
+				return resp, nil
+			}
+			// the bucket now waits out Retry-After; the jitter spreads the queued calls
+			delay = time.Duration(rand.Int64N(int64(retryJitter)))
+		case resp.StatusCode >= http.StatusInternalServerError:
+			if !canRetry {
+				return resp, nil
+			}
+			delay = backoff(attempt)
+		default:
+			return resp, nil
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		s.mu.Lock()
+		b.stats.Retries++
+		s.mu.Unlock()
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// bucket returns the bucket for key, creating it on first use
+func (s *scheduler) bucket(key string, tier rateTier) *bucket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tier: tier, stats: RateLimitStats{Bucket: key}}
+		s.buckets[key] = b
+	}
+	return b
+}
+
+// wait blocks until the bucket has a slot for the call, failing early when ctx ends or
+// its deadline falls before the slot
+func (s *scheduler) wait(ctx context.Context, b *bucket, method string) error {
+	now := time.Now()
+	s.mu.Lock()
+	delay := b.reserve(now)
+	tat := b.tat
+	if delay > 0 {
+		b.stats.Queued++
+		b.stats.Throttled++
+	}
+	s.mu.Unlock()
+	if delay == 0 {
+		return nil
+	}
+
+	var err error
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		err = fmt.Errorf("%s is rate limited for another %s, past the call's deadline: %w", method, delay.Round(time.Second), context.DeadlineExceeded)
+	} else {
+		err = sleepContext(ctx, delay)
+	}
+
+	s.mu.Lock()
+	b.stats.Queued--
+	if err != nil {
+		b.release(tat)
+	}
+	s.mu.Unlock()
+	return err
+}
+
+// Stats returns the state of every bucket, sorted by name
+func (s *scheduler) Stats() []RateLimitStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make([]RateLimitStats, 0, len(s.buckets))
+	for _, b := range s.buckets {
+		stats = append(stats, b.stats)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Bucket < stats[j].Bucket
+	})
+	return stats
+}
+
+// channelOf reads the channel a chat.postMessage request posts to, from its form or JSON body
+func channelOf(req *http.Request) string {
+	if channel := req.URL.Query().Get("channel"); channel != "" || req.GetBody == nil {
+		return channel
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, maxPeekBytes))
+	if err != nil {
+		return ""
+	}
+
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		var message struct {
+			Channel string `json:"channel"`
+		}
+		// a body that does not decode names no channel; the call is then paced per method only
+		_ = json.Unmarshal(data, &message)
+		return message.Channel
+	}
+	values, _ := url.ParseQuery(string(data))
+	return values.Get("channel")
+}
+
+// rewind returns a copy of req with a fresh body for another attempt
+func rewind(req *http.Request) (*http.Request, error) {
+	retry := req.Clone(req.Context())
+	if req.Body != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	return retry, nil
+}
+
+// retryAfterOf returns the Retry-After of a 429 response, one second if Slack gave none
+func retryAfterOf(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return time.Second
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// backoff returns the jittered delay before retry number attempt+1
+func backoff(attempt int) time.Duration {
+	delay := retryBackoff << attempt
+	return delay/2 + time.Duration(rand.Int64N(int64(delay/2)))
+}
+
+// sleepContext sleeps for d or until ctx ends
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
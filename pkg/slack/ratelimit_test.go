@@ -0,0 +1,55 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBucketReserve(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	b := &bucket{tier: tier2}
+
+	// the burst goes through at once, then calls are paced at the sustained rate
+	for i := 0; i < 3; i++ {
+		if delay := b.reserve(now); delay != 0 {
+			t.Fatalf("call %d in the burst waits %s", i+1, delay)
+		}
+	}
+	if delay := b.reserve(now); delay != 3*time.Second {
+		t.Errorf("first call past the burst waits %s, want 3s", delay)
+	}
+
+	// a caller giving up hands its slot back
+	b.release(b.tat)
+	if delay := b.reserve(now); delay != 3*time.Second {
+		t.Errorf("call after a release waits %s, want 3s", delay)
+	}
+
+	// Retry-After holds every call of the bucket
+	b = &bucket{tier: tier3}
+	b.block(now.Add(10 * time.Second))
+	if delay := b.reserve(now); delay != 10*time.Second {
+		t.Errorf("call while blocked waits %s, want 10s", delay)
+	}
+}
+
+func TestSchedulerWaitFailsPastDeadline(t *testing.T) {
+	s := newScheduler(nil)
+	b := s.bucket("chat.postMessage C1", postMessageTier)
+	if err := s.wait(context.Background(), b, "chat.postMessage"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := s.wait(ctx, b, "chat.postMessage")
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 50*time.Millisecond {
+		t.Errorf("wait = %v after %s, want an immediate deadline error", err, time.Since(start))
+	}
+	if stats := s.Stats(); stats[0].Queued != 0 || stats[0].Throttled != 1 {
+		t.Errorf("stats = %+v", stats[0])
+	}
+}
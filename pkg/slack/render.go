@@ -0,0 +1,510 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// mrkdwnTokenPattern matches Slack's angle-bracket tokens: <@U123>, <#C123|name>, <!here>, <https://x|label>
+var mrkdwnTokenPattern = regexp.MustCompile(`<([^<>\s][^<>]*)>`)
+
+// entityReplacer undoes the only three escapes Slack applies to message text
+var entityReplacer = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&")
+
+// mentionCache remembers the names mentions resolve to for the lifetime of the client.
+// Definitive misses (unknown IDs, missing scopes) are cached as "" so they cost one API call
+// at most; rate limits, server and network errors are not, and are retried next time. The
+// lock is not held across API calls, so concurrent renders may look up the same ID twice.
+type mentionCache struct {
+	mu               sync.Mutex
+	users            map[string]string
+	channels         map[string]string
+	usergroups       map[string]string
+	usergroupsLoaded bool
+}
+
+// definitiveMissErrors are the lookup errors that will not go away by retrying
+var definitiveMissErrors = map[string]bool{
+	"user_not_found":    true,
+	"channel_not_found": true,
+	"missing_scope":     true,
+}
+
+// isDefinitiveMiss reports whether a failed lookup can be cached as a miss
+func isDefinitiveMiss(err error) bool {
+	var slackErr slack.SlackErrorResponse
+	return errors.As(err, &slackErr) && definitiveMissErrors[slackErr.Err]
+}
+
+// cachedMention looks key up in one of the mention maps, calling lookup outside the lock on
+// a miss. Names found and definitive misses are stored; other failures return "" uncached.
+func (c *Client) cachedMention(cache *map[string]string, key string, lookup func() (string, error)) string {
+	c.mentions.mu.Lock()
+	name, ok := (*cache)[key]
+	c.mentions.mu.Unlock()
+	if ok {
+		return name
+	}
+
+	name, err := lookup()
+	if err != nil && !isDefinitiveMiss(err) {
+		return ""
+	}
+
+	c.mentions.mu.Lock()
+	defer c.mentions.mu.Unlock()
+	if *cache == nil {
+		*cache = make(map[string]string)
+	}
+	(*cache)[key] = name
+	return name
+}
+
+// userName returns the display name of a user, or "" when it cannot be looked up
+func (c *Client) userName(ctx context.Context, userID string) string {
+	return c.cachedMention(&c.mentions.users, userID, func() (string, error) {
+		user, err := c.api.GetUserInfoContext(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+		return displayName(user), nil
+	})
+}
+
+// channelName returns the name of a conversation, or "" for direct messages and unknown IDs
+func (c *Client) channelName(ctx context.Context, channelID string) string {
+	return c.cachedMention(&c.mentions.channels, channelID, func() (string, error) {
+		channel, err := c.api.GetConversationInfoContext(ctx, &slack.GetConversationInfoInput{ChannelID: channelID})
+		if err != nil {
+			return "", err
+		}
+		return channel.Name, nil
+	})
+}
+
+// usergroupHandle returns the handle of a user group, or "" when usergroups.list is not available
+func (c *Client) usergroupHandle(ctx context.Context, usergroupID string) string {
+	c.mentions.mu.Lock()
+	loaded := c.mentions.usergroupsLoaded
+	handle := c.mentions.usergroups[usergroupID]
+	c.mentions.mu.Unlock()
+	if loaded {
+		return handle
+	}
+
+	// needs usergroups:read; without it mentions fall back to their label or ID
+	groups, err := c.api.GetUserGroupsContext(ctx)
+	if err != nil && !isDefinitiveMiss(err) {
+		return ""
+	}
+	handles := make(map[string]string, len(groups))
+	for _, group := range groups {
+		handles[group.ID] = group.Handle
+	}
+
+	c.mentions.mu.Lock()
+	defer c.mentions.mu.Unlock()
+	c.mentions.usergroupsLoaded = true
+	c.mentions.usergroups = handles
+	return handles[usergroupID]
+}
+
+// displayName picks the name people see for a user in the Slack client
+func displayName(user *slack.User) string {
+	switch {
+	case user.Profile.DisplayName != "":
+		return user.Profile.DisplayName
+	case user.RealName != "":
+		return user.RealName
+	default:
+		return user.Name
+	}
+}
+
+// RenderText turns mrkdwn as Slack stores it into readable text: mentions become @name, #channel
+// and @group, links become [label](url) and the &amp;, &lt; and &gt; escapes are undone.
+func (c *Client) RenderText(ctx context.Context, text string) string {
+	if text == "" {
+		return ""
+	}
+	text = mrkdwnTokenPattern.ReplaceAllStringFunc(text, func(token string) string {
+		return c.renderToken(ctx, token[1:len(token)-1])
+	})
+	return entityReplacer.Replace(text)
+}
+
+// renderToken renders the inside of one <...> token
+func (c *Client) renderToken(ctx context.Context, token string) string {
+	value, label, _ := strings.Cut(token, "|")
+	switch {
+	case strings.HasPrefix(value, "@"):
+		if name := c.userName(ctx, value[1:]); name != "" {
+			return "@" + name
+		}
+		return "@" + firstNonEmpty(label, value[1:])
+	case strings.HasPrefix(value, "#"):
+		if name := c.channelName(ctx, value[1:]); name != "" {
+			return "#" + name
+		}
+		return "#" + firstNonEmpty(label, value[1:])
+	case strings.HasPrefix(value, "!subteam^"):
+		usergroupID := strings.TrimPrefix(value, "!subteam^")
+		if handle := c.usergroupHandle(ctx, usergroupID); handle != "" {
+			return "@" + handle
+		}
+		return firstNonEmpty(label, "@"+usergroupID)
+	case strings.HasPrefix(value, "!date^"):
+		// <!date^1392734382^{date_short}|Feb 18, 2014> carries its own fallback text
+		if label != "" {
+			return label
+		}
+		parts := strings.Split(value, "^")
+		if unix, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+			return time.Unix(unix, 0).UTC().Format("2006-01-02 15:04 UTC")
+		}
+		return value
+	case strings.HasPrefix(value, "!"):
+		// <!here>, <!channel> and <!everyone>
+		return "@" + strings.TrimPrefix(firstNonEmpty(label, value[1:]), "@")
+	case label == "" || label == value:
+		return strings.TrimPrefix(value, "mailto:")
+	case strings.HasPrefix(value, "mailto:"):
+		return label
+	default:
+		return fmt.Sprintf("[%s](%s)", label, value)
+	}
+}
+
+// RenderMessages converts messages into their compact readable form
+func (c *Client) RenderMessages(ctx context.Context, messages []slack.Message) []*RenderedMessage {
+	rendered := make([]*RenderedMessage, 0, len(messages))
+	for i := range messages {
+		rendered = append(rendered, c.RenderMessage(ctx, &messages[i]))
+	}
+	return rendered
+}
+
+// RenderMessage converts a message into its compact readable form. The text comes from the
+// message's blocks when they have any, since Text is only a notification fallback for many
+// apps, followed by its attachments.
+func (c *Client) RenderMessage(ctx context.Context, msg *slack.Message) *RenderedMessage {
+	rendered := &RenderedMessage{
+		Timestamp:  msg.Timestamp,
+		Time:       timestampTime(msg.Timestamp),
+		User:       msg.User,
+		SubType:    msg.SubType,
+		ReplyCount: msg.ReplyCount,
+		Edited:     msg.Edited != nil,
+	}
+	if msg.ThreadTimestamp != "" && msg.ThreadTimestamp != msg.Timestamp {
+		rendered.ThreadTS = msg.ThreadTimestamp
+	}
+
+	switch {
+	case msg.User != "":
+		rendered.UserName = c.userName(ctx, msg.User)
+	case msg.Username != "":
+		rendered.UserName = msg.Username
+	case msg.BotProfile != nil:
+		rendered.UserName = msg.BotProfile.Name
+	}
+
+	text := c.renderBlocks(ctx, msg.Blocks.BlockSet)
+	if text == "" {
+		text = c.RenderText(ctx, msg.Text)
+	}
+	for _, attachment := range msg.Attachments {
+		if flattened := c.renderAttachment(ctx, &attachment); flattened != "" {
+			text = joinNonEmpty("\n", text, flattened)
+		}
+	}
+	rendered.Text = text
+
+	for _, reaction := range msg.Reactions {
+		rendered.Reactions = append(rendered.Reactions, fmt.Sprintf("%s:%d", reaction.Name, reaction.Count))
+	}
+	for _, file := range msg.Files {
+		rendered.Files = append(rendered.Files, firstNonEmpty(file.Title, file.Name, file.ID))
+	}
+	return rendered
+}
+
+// RenderSearchMatches converts search matches into the same compact form as messages
+func (c *Client) RenderSearchMatches(ctx context.Context, matches []*SearchMatch) []*RenderedMessage {
+	rendered := make([]*RenderedMessage, 0, len(matches))
+	for _, match := range matches {
+		message := &RenderedMessage{
+			Timestamp: match.Timestamp,
+			Time:      timestampTime(match.Timestamp),
+			Channel:   match.ChannelName,
+			User:      match.User,
+			UserName:  match.Username,
+			Text:      c.RenderText(ctx, match.Text),
+			Permalink: match.Permalink,
+		}
+		if match.User != "" {
+			message.UserName = firstNonEmpty(c.userName(ctx, match.User), match.Username)
+		}
+		rendered = append(rendered, message)
+	}
+	return rendered
+}
+
+// renderBlocks flattens the blocks of a message into text
+func (c *Client) renderBlocks(ctx context.Context, blocks []slack.Block) string {
+	var parts []string
+	for _, block := range blocks {
+		switch b := block.(type) {
+		case *slack.RichTextBlock:
+			for _, element := range b.Elements {
+				parts = append(parts, c.renderRichTextElement(ctx, element))
+			}
+		case *slack.SectionBlock:
+			if b.Text != nil {
+				parts = append(parts, c.renderTextObject(ctx, b.Text))
+			}
+			for _, field := range b.Fields {
+				parts = append(parts, c.renderTextObject(ctx, field))
+			}
+		case *slack.HeaderBlock:
+			if b.Text != nil {
+				parts = append(parts, c.renderTextObject(ctx, b.Text))
+			}
+		case *slack.ContextBlock:
+			var elements []string
+			for _, element := range b.ContextElements.Elements {
+				switch e := element.(type) {
+				case *slack.TextBlockObject:
+					elements = append(elements, c.renderTextObject(ctx, e))
+				case *slack.ImageBlockElement:
+					elements = append(elements, fmt.Sprintf("[image: %s]", e.AltText))
+				}
+			}
+			parts = append(parts, strings.Join(elements, " "))
+		case *slack.ImageBlock:
+			parts = append(parts, fmt.Sprintf("[image: %s]", firstNonEmpty(b.AltText, b.ImageURL)))
+		case *slack.DividerBlock:
+			parts = append(parts, "---")
+		}
+	}
+	return joinNonEmpty("\n", parts...)
+}
+
+// renderTextObject renders a text composition object; plain_text needs no token handling
+func (c *Client) renderTextObject(ctx context.Context, text *slack.TextBlockObject) string {
+	if text.Type == slack.PlainTextType {
+		return text.Text
+	}
+	return c.RenderText(ctx, text.Text)
+}
+
+// renderRichTextElement renders a top-level rich_text element: a paragraph, list, code block or quote
+func (c *Client) renderRichTextElement(ctx context.Context, element slack.RichTextElement) string {
+	switch e := element.(type) {
+	case *slack.RichTextSection:
+		return c.renderRichTextSection(ctx, e.Elements)
+	case *slack.RichTextList:
+		indent := strings.Repeat("  ", e.Indent)
+		lines := make([]string, 0, len(e.Elements))
+		for i, item := range e.Elements {
+			marker := "•"
+			if e.Style == slack.RTEListOrdered {
+				marker = fmt.Sprintf("%d.", i+1)
+			}
+			lines = append(lines, fmt.Sprintf("%s%s %s", indent, marker, c.renderRichTextElement(ctx, item)))
+		}
+		return strings.Join(lines, "\n")
+	case *slack.RichTextPreformatted:
+		return "```\n" + c.renderRichTextSection(ctx, e.Elements) + "\n```"
+	case *slack.RichTextQuote:
+		return "> " + strings.ReplaceAll(c.renderRichTextSection(ctx, e.Elements), "\n", "\n> ")
+	}
+	return ""
+}
+
+// renderRichTextSection renders the inline elements of a rich_text section, keeping their
+// styles in mrkdwn so the result reads the same as a message's text
+func (c *Client) renderRichTextSection(ctx context.Context, elements []slack.RichTextSectionElement) string {
+	var b strings.Builder
+	for _, element := range elements {
+		switch e := element.(type) {
+		case *slack.RichTextSectionTextElement:
+			b.WriteString(styleText(e.Text, e.Style))
+		case *slack.RichTextSectionLinkElement:
+			if e.Text == "" || e.Text == e.URL {
+				b.WriteString(e.URL)
+			} else {
+				fmt.Fprintf(&b, "[%s](%s)", e.Text, e.URL)
+			}
+		case *slack.RichTextSectionUserElement:
+			b.WriteString("@" + firstNonEmpty(c.userName(ctx, e.UserID), e.UserID))
+		case *slack.RichTextSectionChannelElement:
+			b.WriteString("#" + firstNonEmpty(c.channelName(ctx, e.ChannelID), e.ChannelID))
+		case *slack.RichTextSectionUserGroupElement:
+			b.WriteString("@" + firstNonEmpty(c.usergroupHandle(ctx, e.UsergroupID), e.UsergroupID))
+		case *slack.RichTextSectionEmojiElement:
+			b.WriteString(":" + e.Name + ":")
+		case *slack.RichTextSectionBroadcastElement:
+			b.WriteString("@" + e.Range)
+		case *slack.RichTextSectionDateElement:
+			b.WriteString(time.Unix(int64(e.Timestamp), 0).UTC().Format("2006-01-02 15:04 UTC"))
+		case *slack.RichTextSectionColorElement:
+			b.WriteString(e.Value)
+		}
+	}
+	return b.String()
+}
+
+// styleText wraps rich text in the mrkdwn markers for its style
+func styleText(text string, style *slack.RichTextSectionTextStyle) string {
+	trimmed := strings.TrimSpace(text)
+	if style == nil || trimmed == "" {
+		return text
+	}
+	// markers only work hugging the text, so surrounding spaces stay outside them
+	start := strings.Index(text, trimmed)
+	leading, trailing := text[:start], text[start+len(trimmed):]
+	text = trimmed
+	if style.Code {
+		return leading + "`" + text + "`" + trailing
+	}
+	if style.Bold {
+		text = "*" + text + "*"
+	}
+	if style.Italic {
+		text = "_" + text + "_"
+	}
+	if style.Strike {
+		text = "~" + text + "~"
+	}
+	return leading + text + trailing
+}
+
+// renderAttachment flattens a legacy attachment into quoted lines
+func (c *Client) renderAttachment(ctx context.Context, attachment *slack.Attachment) string {
+	var lines []string
+	if attachment.Pretext != "" {
+		lines = append(lines, c.RenderText(ctx, attachment.Pretext))
+	}
+	if attachment.Title != "" {
+		if attachment.TitleLink != "" {
+			lines = append(lines, fmt.Sprintf("[%s](%s)", attachment.Title, attachment.TitleLink))
+		} else {
+			lines = append(lines, attachment.Title)
+		}
+	}
+	if attachment.Text != "" {
+		lines = append(lines, c.RenderText(ctx, attachment.Text))
+	}
+	for _, field := range attachment.Fields {
+		lines = append(lines, fmt.Sprintf("%s: %s", field.Title, c.RenderText(ctx, field.Value)))
+	}
+	if text := c.renderBlocks(ctx, attachment.Blocks.BlockSet); text != "" {
+		lines = append(lines, text)
+	}
+	if len(lines) == 0 && attachment.Fallback != "" {
+		lines = append(lines, c.RenderText(ctx, attachment.Fallback))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "> " + strings.ReplaceAll(strings.Join(lines, "\n"), "\n", "\n> ")
+}
+
+// FormatTranscript writes rendered messages as a Markdown transcript, one paragraph per message.
+// Replies are indented under their thread when the parent is part of the transcript.
+func FormatTranscript(messages []*RenderedMessage) string {
+	parents := make(map[string]bool, len(messages))
+	for _, msg := range messages {
+		if msg.ThreadTS == "" {
+			parents[msg.Timestamp] = true
+		}
+	}
+
+	var b strings.Builder
+	for i, msg := range messages {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		prefix := ""
+		if msg.ThreadTS != "" && parents[msg.ThreadTS] {
+			prefix = "> "
+		}
+
+		header := "**" + firstNonEmpty(msg.UserName, msg.User, "unknown") + "**"
+		if msg.Channel != "" {
+			header += " in #" + msg.Channel
+		}
+		details := []string{firstNonEmpty(msg.Time, msg.Timestamp)}
+		if msg.Time != "" {
+			details = append(details, "ts "+msg.Timestamp)
+		}
+		if msg.SubType != "" {
+			details = append(details, msg.SubType)
+		}
+		if msg.Edited {
+			details = append(details, "edited")
+		}
+		fmt.Fprintf(&b, "%s%s (%s):\n", prefix, header, strings.Join(details, ", "))
+
+		if msg.Text != "" {
+			fmt.Fprintf(&b, "%s%s\n", prefix, strings.ReplaceAll(msg.Text, "\n", "\n"+prefix))
+		}
+		var extras []string
+		if len(msg.Files) > 0 {
+			extras = append(extras, "files: "+strings.Join(msg.Files, ", "))
+		}
+		if len(msg.Reactions) > 0 {
+			extras = append(extras, "reactions: "+strings.Join(msg.Reactions, " "))
+		}
+		if msg.ReplyCount > 0 {
+			extras = append(extras, fmt.Sprintf("%d replies", msg.ReplyCount))
+		}
+		if msg.Permalink != "" {
+			extras = append(extras, msg.Permalink)
+		}
+		if len(extras) > 0 {
+			fmt.Fprintf(&b, "%s_%s_\n", prefix, strings.Join(extras, " · "))
+		}
+	}
+	return b.String()
+}
+
+// timestampTime formats a Slack timestamp as an RFC 3339 UTC time, or "" when it does not parse
+func timestampTime(ts string) string {
+	seconds, _, _ := strings.Cut(ts, ".")
+	unix, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return ""
+	}
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
+
+// firstNonEmpty returns the first of values that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// joinNonEmpty joins the values that are not empty
+func joinNonEmpty(sep string, values ...string) string {
+	var kept []string
+	for _, value := range values {
+		if value != "" {
+			kept = append(kept, value)
+		}
+	}
+	return strings.Join(kept, sep)
+}
@@ -0,0 +1,57 @@
+package slack
+
+import (
+	"context"
+	"testing"
+
+	"github.com/slack-go/slack"
+
+	"github.com/shawnzhang/slack-go/pkg/fakeslack"
+)
+
+func TestMentionCache(t *testing.T) {
+	client, fake := newTestClient(t)
+	fake.AddUserGroup(slack.UserGroup{ID: "S0ONCALL01", Handle: "oncall"})
+	ctx := context.Background()
+
+	// a transient failure is retried on the next render
+	fake.Fail("users.info", "internal_error")
+	if name := client.userName(ctx, fakeslack.UserID); name != "" {
+		t.Errorf("userName during an outage = %q, want empty", name)
+	}
+	if name := client.userName(ctx, fakeslack.UserID); name != fakeslack.UserName {
+		t.Errorf("userName after the outage = %q, want %s", name, fakeslack.UserName)
+	}
+	fake.Fail("conversations.info", "fatal_error")
+	client.channelName(ctx, "C0GENERAL1")
+	if name := client.channelName(ctx, "C0GENERAL1"); name != "general" {
+		t.Errorf("channelName after the outage = %q, want general", name)
+	}
+	fake.Fail("usergroups.list", "internal_error")
+	client.usergroupHandle(ctx, "S0ONCALL01")
+	if handle := client.usergroupHandle(ctx, "S0ONCALL01"); handle != "oncall" {
+		t.Errorf("usergroupHandle after the outage = %q, want oncall", handle)
+	}
+
+	// names and definitive misses are looked up once
+	for i := 0; i < 2; i++ {
+		client.userName(ctx, fakeslack.UserID)
+		client.userName(ctx, "U0MISSING1")
+		client.channelName(ctx, "C0MISSING1")
+		client.usergroupHandle(ctx, "S0MISSING1")
+	}
+	for method, want := range map[string]int{"users.info": 3, "conversations.info": 3, "usergroups.list": 2} {
+		if calls := len(fake.Calls(method)); calls != want {
+			t.Errorf("%s called %d times, want %d", method, calls, want)
+		}
+	}
+
+	// a missing scope will not come back by retrying either
+	fake.SetScopes(fakeslack.BotToken, "chat:write")
+	scopeless := NewClient(fakeslack.BotToken, OptionAPIURL(fake.URL))
+	scopeless.userName(ctx, fakeslack.BotUserID)
+	scopeless.userName(ctx, fakeslack.BotUserID)
+	if calls := len(fake.Calls("users.info")); calls != 4 {
+		t.Errorf("users.info called %d times without users:read, want once more", calls-3)
+	}
+}
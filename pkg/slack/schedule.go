@@ -0,0 +1,199 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// MaxScheduleAhead is how far in the future chat.scheduleMessage accepts a post time
+const MaxScheduleAhead = 120 * 24 * time.Hour
+
+// localLayouts are the ISO 8601 times without a UTC offset ParseScheduleTime reads in the given time zone
+var localLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+// offsetLayouts are the ISO 8601 times with a UTC offset ParseScheduleTime accepts
+var offsetLayouts = []string{time.RFC3339, "2006-01-02T15:04Z07:00"}
+
+// clockLayouts are the times of day accepted after today and tomorrow
+var clockLayouts = []string{"15:04", "3:04pm", "3pm"}
+
+// ParseScheduleTime reads when to post a message: an ISO 8601 time with a UTC offset
+// (2025-03-02T09:00:00+08:00), one without, read in loc (2025-03-02T09:00 or 2025-03-02 09:00),
+// today or tomorrow at a time of day in loc (tomorrow 09:00, tomorrow at 9am), or a duration
+// from now (in 2h, 90m, 1h30m, 3d).
+func ParseScheduleTime(value string, now time.Time, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range offsetLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.In(loc), nil
+		}
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	lower := strings.ToLower(value)
+	for day, offset := range map[string]int{"today": 0, "tomorrow": 1} {
+		clock, ok := strings.CutPrefix(lower, day+" ")
+		if !ok {
+			continue
+		}
+		clock = strings.TrimPrefix(strings.TrimSpace(clock), "at ")
+		for _, layout := range clockLayouts {
+			if t, err := time.Parse(layout, strings.ReplaceAll(clock, " ", "")); err == nil {
+				year, month, date := now.In(loc).Date()
+				return time.Date(year, month, date+offset, t.Hour(), t.Minute(), 0, 0, loc), nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unrecognized time of day %q, expected e.g. 09:00 or 9am", clock)
+	}
+
+	relative := strings.TrimPrefix(strings.TrimPrefix(lower, "in "), "+")
+	if days, ok := strings.CutSuffix(relative, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return now.AddDate(0, 0, n).In(loc), nil
+		}
+	}
+	if d, err := time.ParseDuration(strings.ReplaceAll(relative, " ", "")); err == nil && d > 0 {
+		return now.Add(d).In(loc), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q, expected an ISO 8601 time (2025-03-02T09:00 or 2025-03-02T09:00:00+08:00), "+
+		"today or tomorrow at a time of day (tomorrow 09:00) or a duration from now (in 2h, 3d)", value)
+}
+
+// CheckScheduleTime reports why Slack would refuse to schedule a message at postAt: it must
+// lie in the future and at most MaxScheduleAhead from now
+func CheckScheduleTime(postAt, now time.Time) error {
+	switch latest := now.Add(MaxScheduleAhead); {
+	case !postAt.After(now):
+		return fmt.Errorf("%s is in the past; scheduled messages must be posted later than now (%s)",
+			postAt.Format(time.RFC3339), now.In(postAt.Location()).Format(time.RFC3339))
+	case postAt.After(latest):
+		return fmt.Errorf("%s is more than 120 days ahead; Slack schedules messages until %s at the latest",
+			postAt.Format(time.RFC3339), latest.In(postAt.Location()).Format(time.RFC3339))
+	}
+	return nil
+}
+
+// UserLocation returns the time zone set in a user's profile, or nil when the user set none
+func (c *Client) UserLocation(ctx context.Context, userID string) (*time.Location, error) {
+	user, err := c.api.GetUserInfoContext(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TZ == "" {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(user.TZ)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q in the profile of %s: %w", user.TZ, userID, err)
+	}
+	return loc, nil
+}
+
+// ScheduleMessage queues a message, built like PostMessage builds it, for Slack to post at
+// postAt. chat.scheduleMessage is called directly since slack-go drops the
+// scheduled_message_id the message can be deleted with.
+func (c *Client) ScheduleMessage(ctx context.Context, params *PostMessageParameters, postAt time.Time) (*ScheduledMessage, error) {
+	if err := CheckScheduleTime(postAt, time.Now()); err != nil {
+		return nil, err
+	}
+
+	values := url.Values{
+		"channel": {params.ChannelID},
+		"post_at": {strconv.FormatInt(postAt.Unix(), 10)},
+		"text":    {params.Text},
+	}
+	if len(params.Blocks) > 0 {
+		blocks, err := json.Marshal(params.Blocks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode blocks: %w", err)
+		}
+		values.Set("blocks", string(blocks))
+	}
+	if len(params.Attachments) > 0 {
+		attachments, err := json.Marshal(params.Attachments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode attachments: %w", err)
+		}
+		values.Set("attachments", string(attachments))
+	}
+	if params.UnfurlLinks != nil {
+		values.Set("unfurl_links", strconv.FormatBool(*params.UnfurlLinks))
+	}
+	if params.UnfurlMedia != nil {
+		values.Set("unfurl_media", strconv.FormatBool(*params.UnfurlMedia))
+	}
+	if params.Mrkdwn != nil {
+		values.Set("mrkdwn", strconv.FormatBool(*params.Mrkdwn))
+	}
+	if params.ThreadTS != "" {
+		values.Set("thread_ts", params.ThreadTS)
+		if params.ReplyBroadcast {
+			values.Set("reply_broadcast", "true")
+		}
+	}
+
+	var response struct {
+		slack.SlackResponse
+		Channel            string `json:"channel"`
+		ScheduledMessageID string `json:"scheduled_message_id"`
+	}
+	if _, err := c.callMethod(ctx, "chat.scheduleMessage", values, &response); err != nil {
+		return nil, err
+	}
+	return &ScheduledMessage{
+		ID:         response.ScheduledMessageID,
+		Channel:    response.Channel,
+		PostAt:     postAt.Unix(),
+		PostAtTime: postAt.Format(time.RFC3339),
+		Text:       params.Text,
+		ThreadTS:   params.ThreadTS,
+	}, nil
+}
+
+// ListScheduledMessages lists the messages the token has scheduled and Slack has not posted yet
+func (c *Client) ListScheduledMessages(ctx context.Context, params *ListScheduledMessagesParameters) (*ListScheduledMessagesResponse, error) {
+	messages, nextCursor, err := c.api.GetScheduledMessagesContext(ctx, &slack.GetScheduledMessagesParameters{
+		Channel: params.ChannelID,
+		Limit:   params.Limit,
+		Cursor:  params.Cursor,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ListScheduledMessagesResponse{
+		ScheduledMessages: make([]*ScheduledMessage, 0, len(messages)),
+		NextCursor:        nextCursor,
+	}
+	for _, msg := range messages {
+		result.ScheduledMessages = append(result.ScheduledMessages, &ScheduledMessage{
+			ID:          msg.ID,
+			Channel:     msg.Channel,
+			PostAt:      int64(msg.PostAt),
+			PostAtTime:  time.Unix(int64(msg.PostAt), 0).UTC().Format(time.RFC3339),
+			Text:        msg.Text,
+			DateCreated: int64(msg.DateCreated),
+		})
+	}
+	return result, nil
+}
+
+// DeleteScheduledMessage removes a scheduled message from Slack's queue before it is posted
+func (c *Client) DeleteScheduledMessage(ctx context.Context, channelID, scheduledMessageID string) error {
+	_, err := c.api.DeleteScheduledMessageContext(ctx, &slack.DeleteScheduledMessageParameters{
+		Channel:            channelID,
+		ScheduledMessageID: scheduledMessageID,
+	})
+	return err
+}
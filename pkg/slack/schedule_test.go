@@ -0,0 +1,49 @@
+package slack
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseScheduleTime(t *testing.T) {
+	singapore := time.FixedZone("SGT", 8*3600)
+	// today and tomorrow are Singapore's, where it is already 2 March, 00:30
+	now := time.Date(2025, 3, 1, 16, 30, 0, 0, time.UTC)
+
+	for value, want := range map[string]string{
+		"2025-03-05T09:00":          "2025-03-05T09:00:00+08:00",
+		"2025-03-05 09:00:30":       "2025-03-05T09:00:30+08:00",
+		"2025-03-05T09:00:00Z":      "2025-03-05T17:00:00+08:00",
+		"2025-03-05T09:00:00-05:00": "2025-03-05T22:00:00+08:00",
+		"tomorrow 09:00":            "2025-03-03T09:00:00+08:00",
+		"Tomorrow at 9am":           "2025-03-03T09:00:00+08:00",
+		"today 11:45pm":             "2025-03-02T23:45:00+08:00",
+		"in 2h":                     "2025-03-02T02:30:00+08:00",
+		"1h30m":                     "2025-03-02T02:00:00+08:00",
+		"3d":                        "2025-03-05T00:30:00+08:00",
+	} {
+		got, err := ParseScheduleTime(value, now, singapore)
+		if err != nil || got.Format(time.RFC3339) != want {
+			t.Errorf("ParseScheduleTime(%q) = %s, %v; want %s", value, got.Format(time.RFC3339), err, want)
+		}
+	}
+	for _, value := range []string{"next week", "tomorrow noon", "2025-03-05", "-2h", "0d"} {
+		if got, err := ParseScheduleTime(value, now, singapore); err == nil {
+			t.Errorf("ParseScheduleTime(%q) = %s, want an error", value, got)
+		}
+	}
+}
+
+func TestCheckScheduleTime(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	if err := CheckScheduleTime(now.Add(MaxScheduleAhead), now); err != nil {
+		t.Errorf("120 days ahead: %v", err)
+	}
+	if err := CheckScheduleTime(now.Add(-time.Minute), now); err == nil || !strings.Contains(err.Error(), "in the past") {
+		t.Errorf("a minute ago: %v", err)
+	}
+	if err := CheckScheduleTime(now.Add(MaxScheduleAhead+time.Second), now); err == nil || !strings.Contains(err.Error(), "2025-06-29T09:00:00Z") {
+		t.Errorf("past 120 days: %v", err)
+	}
+}
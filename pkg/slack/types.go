@@ -4,12 +4,49 @@ import (
 	"github.com/slack-go/slack"
 )
 
+// TokenType is the kind of Slack token a client authenticates with
+type TokenType string
+
+const (
+	// TokenTypeBot is a bot token (xoxb-)
+	TokenTypeBot TokenType = "bot"
+	// TokenTypeUser is a user token (xoxp-)
+	TokenTypeUser TokenType = "user"
+	// TokenTypeApp is an app-level token (xapp-)
+	TokenTypeApp TokenType = "app"
+	// TokenTypeUnknown is any token with an unrecognized prefix
+	TokenTypeUnknown TokenType = "unknown"
+)
+
 // Message represents a Slack message
 type Message struct {
 	Timestamp       string
 	Channel         string
 	Text            string
 	ThreadTimestamp string
+	Permalink       string
+}
+
+// PostMessageParameters represents the parameters for a PostMessage call
+type PostMessageParameters struct {
+	ChannelID string
+	// Text is the message, or the notification fallback when Blocks are set
+	Text string
+	// Blocks are Block Kit blocks, see ParseBlocks
+	Blocks []slack.Block
+	// Attachments are legacy message attachments
+	Attachments []slack.Attachment
+	// UnfurlLinks, UnfurlMedia and Mrkdwn keep Slack's defaults when nil
+	UnfurlLinks *bool
+	UnfurlMedia *bool
+	Mrkdwn      *bool
+	// Username and IconEmoji override the bot's name and icon (requires chat:write.customize)
+	Username  string
+	IconEmoji string
+	// ThreadTS posts the message as a reply in this thread
+	ThreadTS string
+	// ReplyBroadcast also sends a thread reply to the channel
+	ReplyBroadcast bool
 }
 
 // GetUsersResponse represents the response from a GetUsers call
@@ -24,6 +61,24 @@ type GetUsersResponse struct {
 type GetUsersParameters struct {
 	Limit  int
 	Cursor string
+	// IncludeDeleted keeps deactivated users
+	IncludeDeleted bool
+	// IncludeBots keeps bot and app users, including Slackbot
+	IncludeBots bool
+	// IncludeGuests keeps single- and multi-channel guests
+	IncludeGuests bool
+	// TimeZone keeps only users in this IANA time zone (e.g. Asia/Singapore)
+	TimeZone string
+}
+
+// ListChannelsParameters represents the parameters for a ListChannels call
+type ListChannelsParameters struct {
+	Limit  int
+	Cursor string
+	// IncludeArchived keeps archived channels
+	IncludeArchived bool
+	// NamePrefix keeps only channels whose name starts with it (case-insensitive, leading # ignored)
+	NamePrefix string
 }
 
 // GetConversationsResponse represents the response from a GetConversations call
@@ -36,6 +91,8 @@ type GetConversationsResponse struct {
 
 // UserProfileInfo represents filtered user profile information
 type UserProfileInfo struct {
+	// ID is the Slack user ID (e.g. U0123ABCDEF)
+	ID string `json:"id"`
 	// Name is the username of the Slack user (e.g. johndoe)
 	Name string `json:"name"`
 	// FullName is the actual name of the Slack user (e.g. John Doe)
@@ -46,9 +103,232 @@ type UserProfileInfo struct {
 	Email string `json:"email"`
 	// Title is the user's job title or role in the organization
 	Title string `json:"title"`
+	// TimeZone is the user's IANA time zone (e.g. Asia/Singapore)
+	TimeZone string `json:"tz,omitempty"`
 }
 
 // GetThreadRepliesResponse represents the response from a GetThreadReplies call
 type GetThreadRepliesResponse struct {
-	Messages []slack.Message
+	ChannelID string          `json:"channel_id"`
+	ThreadTS  string          `json:"thread_ts"`
+	Messages  []slack.Message `json:"messages"`
+	// Truncated is set when the thread has more messages than the caller's cap
+	Truncated bool `json:"truncated"`
+}
+
+// GetChannelHistoryParameters represents the parameters for a GetChannelHistory call
+type GetChannelHistoryParameters struct {
+	ChannelID string
+	// Oldest and Latest bound the time window, as Slack timestamps or RFC 3339 times
+	Oldest    string
+	Latest    string
+	Inclusive bool
+	Limit     int
+	Cursor    string
+}
+
+// GetChannelHistoryResponse represents the response from a GetChannelHistory call
+type GetChannelHistoryResponse struct {
+	Messages   []slack.Message `json:"messages"`
+	HasMore    bool            `json:"has_more"`
+	NextCursor string          `json:"next_cursor"`
+}
+
+// ReactionInfo represents an emoji reaction on a message, aggregated across users
+type ReactionInfo struct {
+	// Name is the emoji name without colons (e.g. white_check_mark)
+	Name string `json:"name"`
+	// Count is the number of users who reacted with this emoji
+	Count int `json:"count"`
+	// Users are the IDs of the users who reacted with this emoji
+	Users []string `json:"users"`
+}
+
+// SearchMessagesParameters represents the parameters for a SearchMessages call
+type SearchMessagesParameters struct {
+	Query string
+	// Sort is "score" or "timestamp"
+	Sort string
+	// SortDirection is "asc" or "desc"
+	SortDirection string
+	Count         int
+	Page          int
+}
+
+// SearchMatch represents a single message matched by a search
+type SearchMatch struct {
+	ChannelID   string `json:"channel_id"`
+	ChannelName string `json:"channel_name"`
+	// User is the author's user ID, Username their handle
+	User      string `json:"user"`
+	Username  string `json:"username"`
+	Timestamp string `json:"ts"`
+	Text      string `json:"text"`
+	Permalink string `json:"permalink"`
+}
+
+// SearchMessagesResponse represents the response from a SearchMessages call
+type SearchMessagesResponse struct {
+	Query     string         `json:"query"`
+	Total     int            `json:"total"`
+	Page      int            `json:"page"`
+	PageCount int            `json:"page_count"`
+	Matches   []*SearchMatch `json:"matches"`
+}
+
+// UserDirectoryPage represents one page of the user directory in the compact profile shape
+type UserDirectoryPage struct {
+	Users      []*UserProfileInfo `json:"users"`
+	NextCursor string             `json:"next_cursor"`
+}
+
+// UserCandidate represents a user matched by a lookup, ranked by confidence
+type UserCandidate struct {
+	UserProfileInfo
+	// Confidence is between 0 and 1; 1 means an exact ID, email or handle match
+	Confidence float64 `json:"confidence"`
+	// MatchedOn is the field that matched: id, email, name, display_name or real_name
+	MatchedOn string `json:"matched_on"`
+}
+
+// ChannelMatch represents a conversation matched by a channel lookup
+type ChannelMatch struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// Type is public_channel, private_channel, mpim or im
+	Type string `json:"type"`
+	// User is the other member of a direct message (im)
+	User       string `json:"user,omitempty"`
+	IsArchived bool   `json:"is_archived"`
+	// Exact is set when the name (or ID) matched the query exactly
+	Exact bool `json:"exact"`
+}
+
+// ChannelInfo represents the compact view of a channel
+type ChannelInfo struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Topic       string `json:"topic"`
+	Purpose     string `json:"purpose"`
+	MemberCount int    `json:"member_count"`
+	IsPrivate   bool   `json:"is_private"`
+	IsArchived  bool   `json:"is_archived"`
+	// Created is the Unix time the channel was created
+	Created int64 `json:"created"`
+}
+
+// ChannelListPage represents one page of the channel list in the compact channel shape
+type ChannelListPage struct {
+	Channels   []*ChannelInfo `json:"channels"`
+	NextCursor string         `json:"next_cursor"`
+}
+
+// RenderedMessage represents the compact readable view of a message: mentions resolved to
+// names, entities unescaped and blocks and attachments flattened into Text
+type RenderedMessage struct {
+	Timestamp string `json:"ts"`
+	// Time is Timestamp as an RFC 3339 UTC time
+	Time string `json:"time,omitempty"`
+	// ThreadTS is the parent message's timestamp, set on thread replies only
+	ThreadTS string `json:"thread_ts,omitempty"`
+	// Channel is the channel name, set on search matches
+	Channel string `json:"channel,omitempty"`
+	// User is the author's user ID, UserName the name shown in Slack (or the bot's name)
+	User     string `json:"user,omitempty"`
+	UserName string `json:"user_name,omitempty"`
+	// SubType is the message subtype, e.g. bot_message or channel_join
+	SubType    string `json:"subtype,omitempty"`
+	Text       string `json:"text"`
+	ReplyCount int    `json:"reply_count,omitempty"`
+	// Reactions are name:count pairs, e.g. eyes:2
+	Reactions []string `json:"reactions,omitempty"`
+	// Files are the titles of the files shared with the message
+	Files     []string `json:"files,omitempty"`
+	Edited    bool     `json:"edited,omitempty"`
+	Permalink string   `json:"permalink,omitempty"`
+}
+
+// RenderedThreadReplies represents the compact view of a GetThreadReplies response
+type RenderedThreadReplies struct {
+	ChannelID string             `json:"channel_id"`
+	ThreadTS  string             `json:"thread_ts"`
+	Messages  []*RenderedMessage `json:"messages"`
+	Truncated bool               `json:"truncated"`
+}
+
+// RenderedChannelHistory represents the compact view of a GetChannelHistory response
+type RenderedChannelHistory struct {
+	Messages   []*RenderedMessage `json:"messages"`
+	HasMore    bool               `json:"has_more"`
+	NextCursor string             `json:"next_cursor"`
+}
+
+// RenderedSearchResults represents the compact view of a SearchMessages response
+type RenderedSearchResults struct {
+	Query     string             `json:"query"`
+	Total     int                `json:"total"`
+	Page      int                `json:"page"`
+	PageCount int                `json:"page_count"`
+	Matches   []*RenderedMessage `json:"matches"`
+}
+
+// Identity represents who a token authenticates as, as reported by auth.test
+type Identity struct {
+	URL    string `json:"url"`
+	Team   string `json:"team"`
+	TeamID string `json:"team_id"`
+	// User is the user the token acts as; for bot tokens, the bot user
+	User   string `json:"user"`
+	UserID string `json:"user_id"`
+	// EnterpriseID is set on Enterprise Grid
+	EnterpriseID string    `json:"enterprise_id,omitempty"`
+	BotID        string    `json:"bot_id,omitempty"`
+	TokenType    TokenType `json:"token_type"`
+	// Scopes are the OAuth scopes granted to the token. ScopesKnown is false when Slack
+	// sent no X-OAuth-Scopes header, e.g. for some legacy tokens.
+	Scopes      []string `json:"scopes"`
+	ScopesKnown bool     `json:"scopes_known"`
+}
+
+// RateLimitStats describes the rate-limit queue of one Web API method, or for
+// chat.postMessage of one channel ("chat.postMessage C0123ABCDEF")
+type RateLimitStats struct {
+	Bucket string `json:"bucket"`
+	// Queued is the number of calls waiting for a slot right now
+	Queued int `json:"queued"`
+	// Throttled counts calls delayed to stay within the limit
+	Throttled int64 `json:"throttled"`
+	// RateLimited counts 429 responses from Slack
+	RateLimited int64 `json:"rate_limited"`
+	// Retries counts repeated attempts of idempotent reads
+	Retries int64 `json:"retries"`
+}
+
+// ScheduledMessage is a message waiting in Slack's queue to be posted
+type ScheduledMessage struct {
+	ID      string `json:"scheduled_message_id"`
+	Channel string `json:"channel"`
+	// PostAt is when Slack will post the message, in Unix seconds
+	PostAt int64 `json:"post_at"`
+	// PostAtTime is PostAt as an RFC 3339 time in the time zone the message was scheduled in,
+	// or UTC when listed
+	PostAtTime string `json:"post_at_time"`
+	Text       string `json:"text,omitempty"`
+	ThreadTS   string `json:"thread_ts,omitempty"`
+	// DateCreated is when the message was scheduled, in Unix seconds; set when listed
+	DateCreated int64 `json:"date_created,omitempty"`
+}
+
+// ListScheduledMessagesParameters represents the parameters for a ListScheduledMessages call
+type ListScheduledMessagesParameters struct {
+	// ChannelID limits the list to one conversation; empty lists every conversation
+	ChannelID string
+	Limit     int
+	Cursor    string
+}
+
+// ListScheduledMessagesResponse represents the response from a ListScheduledMessages call
+type ListScheduledMessagesResponse struct {
+	ScheduledMessages []*ScheduledMessage `json:"scheduled_messages"`
+	NextCursor        string              `json:"next_cursor,omitempty"`
 }
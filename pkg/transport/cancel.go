@@ -0,0 +1,129 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// methodCancelled is the notification a client sends to abort one of its requests
+const methodCancelled = "notifications/cancelled"
+
+// errCancelledByClient is the cause of the context of a request the client cancelled
+var errCancelledByClient = errors.New("request cancelled by the client")
+
+// inflight tracks the requests being handled, by session and JSON-RPC ID, so that a
+// notifications/cancelled from the client can abort them along with their Slack calls
+type inflight struct {
+	mu       sync.Mutex
+	requests map[string]context.CancelCauseFunc
+}
+
+// newInflight returns an empty request tracker
+func newInflight() *inflight {
+	return &inflight{requests: make(map[string]context.CancelCauseFunc)}
+}
+
+// jsonrpcHeader holds the fields of a JSON-RPC message needed to track and cancel requests
+type jsonrpcHeader struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params struct {
+		RequestID json.RawMessage `json:"requestId"`
+		Reason    string          `json:"reason"`
+	} `json:"params"`
+}
+
+// begin returns the context to handle a message with. Requests get a context that a
+// cancellation can end; done must be called once the message has been handled. Per the
+// MCP specification, no response is sent for a request the client cancelled, which
+// cancelledByClient reports.
+func (f *inflight) begin(ctx context.Context, sessionID string, message json.RawMessage) (context.Context, func()) {
+	var header jsonrpcHeader
+	if json.Unmarshal(message, &header) != nil || len(header.ID) == 0 || header.Method == "" {
+		return ctx, func() {}
+	}
+
+	key := requestKey(sessionID, header.ID)
+	ctx, cancel := context.WithCancelCause(ctx)
+	f.mu.Lock()
+	f.requests[key] = cancel
+	f.mu.Unlock()
+	return ctx, func() {
+		f.mu.Lock()
+		delete(f.requests, key)
+		f.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// cancelledByClient reports whether a request's context was ended by a notifications/cancelled
+func cancelledByClient(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errCancelledByClient)
+}
+
+// cancel handles a notifications/cancelled message, aborting the request it names if that
+// request is still running. It reports whether the message was a cancellation.
+func (f *inflight) cancel(sessionID string, message json.RawMessage) bool {
+	var header jsonrpcHeader
+	if json.Unmarshal(message, &header) != nil || header.Method != methodCancelled {
+		return false
+	}
+
+	f.mu.Lock()
+	cancel, ok := f.requests[requestKey(sessionID, header.Params.RequestID)]
+	f.mu.Unlock()
+	if ok {
+		log.Printf("client cancelled request %s: %s", header.Params.RequestID, header.Params.Reason)
+		cancel(errCancelledByClient)
+	}
+	return true
+}
+
+// cancelAll aborts every running request, e.g. when shutdown takes too long
+func (f *inflight) cancelAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, cancel := range f.requests {
+		cancel(context.Canceled)
+	}
+}
+
+// requestKey identifies a request within its session; IDs 1 and "1" are different requests
+func requestKey(sessionID string, id json.RawMessage) string {
+	return sessionID + " " + string(bytes.TrimSpace(id))
+}
+
+// withCancellation lets SSE clients cancel requests: cancellations POSTed to the message
+// endpoint end the request they name, which is handled with a context they can cancel
+func withCancellation(f *inflight, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxMessageBytes+1))
+		if err != nil || len(body) > maxMessageBytes {
+			writeJSONRPCError(w, http.StatusBadRequest, mcp.PARSE_ERROR, "failed to read request body")
+			return
+		}
+
+		sessionID := r.URL.Query().Get("sessionId")
+		if f.cancel(sessionID, body) {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		ctx, done := f.begin(r.Context(), sessionID, body)
+		defer done()
+		r = r.WithContext(ctx)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
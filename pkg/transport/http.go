@@ -0,0 +1,176 @@
+package transport
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Transport names accepted by the --transport flag
+const (
+	Stdio          = "stdio"
+	SSE            = "sse"
+	StreamableHTTP = "streamable-http"
+)
+
+// shutdownTimeout is how long in-flight requests get to finish once shutdown starts
+const shutdownTimeout = 15 * time.Second
+
+// Config configures the HTTP listener of the sse and streamable-http transports
+type Config struct {
+	// Transport is SSE or StreamableHTTP
+	Transport string
+	// Addr is the listen address, e.g. :8080 or 127.0.0.1:8080
+	Addr string
+	// BasePath prefixes every endpoint, e.g. /slack serves /slack/mcp
+	BasePath string
+	// AuthToken is the bearer token clients must send; empty disables authentication
+	AuthToken string
+	// Subscriptions handles resource subscriptions; nil leaves them unsupported
+	Subscriptions Subscriptions
+	// Events is the Slack Events API Request URL handler, served at /events without bearer
+	// authentication since Slack signs its requests instead; nil leaves it unserved
+	Events http.Handler
+}
+
+// ServeHTTP serves the MCP server over HTTP until ctx is done, then shuts down gracefully:
+// readiness turns 503, event streams are closed and in-flight requests get shutdownTimeout
+// to finish. Endpoints under BasePath:
+//
+//	/healthz          liveness, always 200
+//	/readyz           readiness, 503 while shutting down
+//	/mcp              Streamable HTTP endpoint (streamable-http)
+//	/sse, /message    SSE stream and message endpoints (sse)
+//	/events           Slack Events API Request URL, when Events is set
+func ServeHTTP(ctx context.Context, mcpServer *server.MCPServer, config Config) error {
+	basePath := "/" + strings.Trim(config.BasePath, "/")
+	if basePath == "/" {
+		basePath = ""
+	}
+
+	// streams ends long-lived event streams at shutdown, which http.Server.Shutdown would otherwise wait for
+	streams, closeStreams := context.WithCancel(context.Background())
+	defer closeStreams()
+
+	var ready atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc(basePath+"/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc(basePath+"/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !ready.Load() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ready")
+	})
+	if config.Events != nil {
+		mux.Handle(basePath+"/events", config.Events)
+	}
+
+	var streamable *StreamableHTTPServer
+	switch config.Transport {
+	case StreamableHTTP:
+		streamable = NewStreamableHTTPServer(mcpServer, config.Subscriptions)
+		mux.Handle(basePath+"/mcp", requireBearer(config.AuthToken, withStreams(streams, streamable)))
+	case SSE:
+		sseServer := server.NewSSEServer(mcpServer, server.WithBasePath(basePath))
+		mux.Handle(sseServer.CompleteSsePath(), requireBearer(config.AuthToken, withStreams(streams, sseServer)))
+		mux.Handle(sseServer.CompleteMessagePath(), requireBearer(config.AuthToken, withCancellation(newInflight(), withSubscriptions(config.Subscriptions, sseServer, sseServer))))
+	default:
+		return fmt.Errorf("transport %q is not served over HTTP", config.Transport)
+	}
+
+	listener, err := net.Listen("tcp", config.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", config.Addr, err)
+	}
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(listener)
+	}()
+	ready.Store(true)
+	log.Printf("serving MCP over %s on http://%s%s", config.Transport, listener.Addr(), basePath)
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("shutting down HTTP listener...")
+	ready.Store(false)
+	closeStreams()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err = srv.Shutdown(shutdownCtx)
+	if streamable != nil {
+		streamable.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to shut down HTTP listener: %w", err)
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// requireBearer rejects requests that do not carry the bearer token. An empty token lets every request through.
+func requireBearer(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="slack-go"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withStreams ends GET requests, the long-lived event streams, when streams is done.
+// Other requests keep their own context so in-flight tool calls can finish during shutdown.
+func withStreams(streams context.Context, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		stop := context.AfterFunc(streams, cancel)
+		defer stop()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// IsLoopback reports whether a listen address only accepts local connections
+func IsLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
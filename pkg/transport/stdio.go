@@ -0,0 +1,139 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ServeStdio serves the MCP server over newline-delimited JSON-RPC on in and out until in
+// is closed or ctx is done. Unlike server.ServeStdio it handles requests concurrently, so a
+// notifications/cancelled can abort a running tool call. In-flight requests get
+// shutdownTimeout to finish once reading stops. subscriptions may be nil when resource
+// subscriptions are not supported.
+func ServeStdio(ctx context.Context, mcpServer *server.MCPServer, subscriptions Subscriptions, in io.Reader, out io.Writer) error {
+	session := &clientSession{
+		id:            "stdio",
+		notifications: make(chan mcp.JSONRPCNotification, notificationBuffer),
+	}
+	if err := mcpServer.RegisterSession(session); err != nil {
+		return fmt.Errorf("failed to register session: %w", err)
+	}
+	defer mcpServer.UnregisterSession(session.id)
+	if subscriptions != nil {
+		defer subscriptions.EndSession(session.id)
+	}
+
+	var writeMu sync.Mutex
+	write := func(message any) {
+		data, err := json.Marshal(message)
+		if err != nil {
+			log.Printf("failed to serialize message: %v", err)
+			return
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if _, err := fmt.Fprintf(out, "%s\n", data); err != nil {
+			log.Printf("failed to write message: %v", err)
+		}
+	}
+
+	// requests keep running when ctx ends, until they finish or the shutdown timeout expires
+	handlerCtx := mcpServer.WithContext(context.WithoutCancel(ctx), session)
+	requests := newInflight()
+	var handlers sync.WaitGroup
+
+	notificationsDone := make(chan struct{})
+	defer close(notificationsDone)
+	go func() {
+		for {
+			select {
+			case notification := <-session.notifications:
+				write(notification)
+			case <-notificationsDone:
+				return
+			}
+		}
+	}()
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				lines <- line
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	var err error
+read:
+	for {
+		select {
+		case line := <-lines:
+			message := json.RawMessage(bytes.TrimSpace(line))
+			if len(message) == 0 {
+				continue
+			}
+			if !json.Valid(message) {
+				response := mcp.JSONRPCError{JSONRPC: mcp.JSONRPC_VERSION}
+				response.Error.Code = mcp.PARSE_ERROR
+				response.Error.Message = "Parse error"
+				write(response)
+				continue
+			}
+			if requests.cancel(session.id, message) {
+				continue
+			}
+			messageCtx, done := requests.begin(handlerCtx, session.id, message)
+			handlers.Add(1)
+			go func() {
+				defer handlers.Done()
+				defer done()
+				response, ok := handleSubscription(messageCtx, subscriptions, session.id, session.notify, message)
+				if !ok {
+					response = mcpServer.HandleMessage(messageCtx, message)
+				}
+				if response != nil && !cancelledByClient(messageCtx) {
+					write(response)
+				}
+			}()
+		case err = <-readErr:
+			if err == io.EOF {
+				err = nil
+			}
+			break read
+		case <-ctx.Done():
+			break read
+		}
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		handlers.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(shutdownTimeout):
+		log.Printf("requests still running after %s, cancelling them", shutdownTimeout)
+		requests.cancelAll()
+		<-finished
+	}
+	return err
+}
@@ -0,0 +1,407 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// sessionHeader carries the session ID of the Streamable HTTP transport
+	sessionHeader = "Mcp-Session-Id"
+	// maxMessageBytes bounds the size of a POSTed JSON-RPC message or batch
+	maxMessageBytes = 4 << 20
+	// notificationBuffer is how many notifications a session holds while no stream is reading them
+	notificationBuffer = 100
+	// sessionIdleTimeout is how long a session may go without requests before it is ended, for
+	// clients that disappear without sending DELETE
+	sessionIdleTimeout = time.Hour
+	// sessionSweepInterval is how often idle sessions are looked for between requests
+	sessionSweepInterval = time.Minute
+	// maxSessions caps the open sessions; the least recently used one is ended to make room
+	maxSessions = 1000
+)
+
+// clientSession is a client of the stdio or Streamable HTTP transport
+type clientSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+	initialized   atomic.Bool
+	// streaming is set while a GET stream delivers the session's notifications
+	streaming atomic.Bool
+	// lastUsed is when the session last had a request, in Unix nanoseconds
+	lastUsed atomic.Int64
+}
+
+// SessionID returns the session's ID, sent in the Mcp-Session-Id header over Streamable HTTP
+func (s *clientSession) SessionID() string {
+	return s.id
+}
+
+// NotificationChannel returns the channel the server queues notifications on
+func (s *clientSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+// Initialize marks the session as ready for notifications
+func (s *clientSession) Initialize() {
+	s.initialized.Store(true)
+}
+
+// Initialized reports whether the session completed initialization
+func (s *clientSession) Initialized() bool {
+	return s.initialized.Load()
+}
+
+// StreamableHTTPServer serves an MCP server over the Streamable HTTP transport: clients POST
+// JSON-RPC messages to a single endpoint and get the responses back as JSON, may open a GET
+// event stream for server notifications and end their session with DELETE.
+type StreamableHTTPServer struct {
+	server        *server.MCPServer
+	subscriptions Subscriptions
+	inflight      *inflight
+	mu            sync.Mutex
+	sessions      map[string]*clientSession
+	idleTimeout   time.Duration
+	maxSessions   int
+	now           func() time.Time
+	// stopSweep ends the goroutine ending idle sessions
+	stopSweep context.CancelFunc
+}
+
+// NewStreamableHTTPServer returns a Streamable HTTP handler for the MCP server. subscriptions
+// may be nil when resource subscriptions are not supported.
+func NewStreamableHTTPServer(mcpServer *server.MCPServer, subscriptions Subscriptions) *StreamableHTTPServer {
+	ctx, stop := context.WithCancel(context.Background())
+	s := &StreamableHTTPServer{
+		server:        mcpServer,
+		subscriptions: subscriptions,
+		inflight:      newInflight(),
+		sessions:      make(map[string]*clientSession),
+		idleTimeout:   sessionIdleTimeout,
+		maxSessions:   maxSessions,
+		now:           time.Now,
+		stopSweep:     stop,
+	}
+	go s.sweepSessions(ctx)
+	return s
+}
+
+// ServeHTTP implements the http.Handler interface
+func (s *StreamableHTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.handlePost(w, r)
+	case http.MethodGet:
+		s.handleStream(w, r)
+	case http.MethodDelete:
+		s.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Close ends every session, e.g. when the listener shuts down
+func (s *StreamableHTTPServer) Close() {
+	s.stopSweep()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := range s.sessions {
+		s.releaseSession(id)
+		delete(s.sessions, id)
+	}
+}
+
+// handlePost processes a JSON-RPC message or batch. Requests are answered in the response
+// body; a body of only notifications and responses is acknowledged with 202 Accepted.
+func (s *StreamableHTTPServer) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMessageBytes+1))
+	if err != nil {
+		writeJSONRPCError(w, http.StatusBadRequest, mcp.PARSE_ERROR, "failed to read request body")
+		return
+	}
+	if len(body) > maxMessageBytes {
+		writeJSONRPCError(w, http.StatusRequestEntityTooLarge, mcp.INVALID_REQUEST, "request body is too large")
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+	batch := len(body) > 0 && body[0] == '['
+	var messages []json.RawMessage
+	if batch {
+		err = json.Unmarshal(body, &messages)
+	} else {
+		messages = []json.RawMessage{body}
+		err = json.Unmarshal(body, new(json.RawMessage))
+	}
+	if err != nil || len(messages) == 0 {
+		writeJSONRPCError(w, http.StatusBadRequest, mcp.PARSE_ERROR, "body must be a JSON-RPC message or a non-empty batch")
+		return
+	}
+
+	var session *clientSession
+	if !batch && isInitialize(messages[0]) {
+		session, err = s.newSession()
+		if err != nil {
+			log.Printf("failed to create session: %v", err)
+			http.Error(w, "failed to create session", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		var status int
+		if session, status = s.sessionOf(r); session == nil {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+	}
+
+	ctx := s.server.WithContext(r.Context(), session)
+	var responses []mcp.JSONRPCMessage
+	for _, message := range messages {
+		if s.inflight.cancel(session.id, message) {
+			continue
+		}
+		messageCtx, done := s.inflight.begin(ctx, session.id, message)
+		response, ok := handleSubscription(messageCtx, s.subscriptions, session.id, session.notify, message)
+		if !ok {
+			response = s.server.HandleMessage(messageCtx, message)
+		}
+		cancelled := cancelledByClient(messageCtx)
+		done()
+		if response != nil && !cancelled {
+			responses = append(responses, response)
+		}
+	}
+
+	if session.Initialized() || !isInitialize(messages[0]) {
+		w.Header().Set(sessionHeader, session.id)
+	} else {
+		// initialize failed, so the session never became usable
+		s.endSession(session.id)
+	}
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if batch {
+		json.NewEncoder(w).Encode(responses)
+	} else {
+		json.NewEncoder(w).Encode(responses[0])
+	}
+}
+
+// handleStream delivers the session's notifications as server-sent events until the client
+// disconnects or the listener shuts down. A session has at most one stream at a time.
+func (s *StreamableHTTPServer) handleStream(w http.ResponseWriter, r *http.Request) {
+	session, status := s.sessionOf(r)
+	if session == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	if !session.streaming.CompareAndSwap(false, true) {
+		http.Error(w, "the session already has an open stream", http.StatusConflict)
+		return
+	}
+	defer func() {
+		session.lastUsed.Store(s.now().UnixNano())
+		session.streaming.Store(false)
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case notification := <-session.notifications:
+			data, err := json.Marshal(notification)
+			if err != nil {
+				log.Printf("failed to serialize notification: %v", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleDelete ends a session at the client's request
+func (s *StreamableHTTPServer) handleDelete(w http.ResponseWriter, r *http.Request) {
+	session, status := s.sessionOf(r)
+	if session == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	s.endSession(session.id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newSession creates and registers a session for an initialize request
+func (s *StreamableHTTPServer) newSession() (*clientSession, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	session := &clientSession{
+		id:            hex.EncodeToString(id),
+		notifications: make(chan mcp.JSONRPCNotification, notificationBuffer),
+	}
+	session.lastUsed.Store(s.now().UnixNano())
+	if err := s.server.RegisterSession(session); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	evicted := s.evictSessions()
+	s.sessions[session.id] = session
+	s.mu.Unlock()
+	for _, id := range evicted {
+		s.releaseSession(id)
+	}
+	return session, nil
+}
+
+// sweepSessions ends idle sessions every sessionSweepInterval until ctx is done, so that
+// sessions nobody returns to release their subscriptions
+func (s *StreamableHTTPServer) sweepSessions(ctx context.Context) {
+	ticker := time.NewTicker(sessionSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		s.mu.Lock()
+		expired := s.expireSessions()
+		s.mu.Unlock()
+		for _, id := range expired {
+			s.releaseSession(id)
+		}
+	}
+}
+
+// expired reports whether a session went without requests for longer than idleTimeout.
+// Sessions with an open stream are in use and never expire.
+func (s *StreamableHTTPServer) expired(session *clientSession) bool {
+	return !session.streaming.Load() && session.lastUsed.Load() < s.now().Add(-s.idleTimeout).UnixNano()
+}
+
+// expireSessions forgets the expired sessions, returning their IDs for the caller to release
+// once s.mu is unlocked. Must be called with s.mu held.
+func (s *StreamableHTTPServer) expireSessions() []string {
+	var expired []string
+	for id, session := range s.sessions {
+		if s.expired(session) {
+			delete(s.sessions, id)
+			expired = append(expired, id)
+			log.Printf("ending session %s after %s without requests", id, s.idleTimeout)
+		}
+	}
+	return expired
+}
+
+// evictSessions forgets the expired sessions and, while the server is still at maxSessions,
+// the least recently used ones, returning their IDs for the caller to release once s.mu is
+// unlocked. Sessions with an open stream are kept even past the cap. Must be called with s.mu held.
+func (s *StreamableHTTPServer) evictSessions() []string {
+	evicted := s.expireSessions()
+	for len(s.sessions) >= s.maxSessions {
+		var oldest *clientSession
+		for _, session := range s.sessions {
+			if !session.streaming.Load() && (oldest == nil || session.lastUsed.Load() < oldest.lastUsed.Load()) {
+				oldest = session
+			}
+		}
+		if oldest == nil {
+			break
+		}
+		delete(s.sessions, oldest.id)
+		evicted = append(evicted, oldest.id)
+		log.Printf("ending least recently used session %s: %d sessions are open", oldest.id, s.maxSessions)
+	}
+	return evicted
+}
+
+// sessionOf returns the session named by the request's Mcp-Session-Id header, or nil with
+// the status to answer: 400 when the header is missing, 404 when the session is unknown,
+// ended or expired since the last sweep
+func (s *StreamableHTTPServer) sessionOf(r *http.Request) (*clientSession, int) {
+	id := r.Header.Get(sessionHeader)
+	if id == "" {
+		return nil, http.StatusBadRequest
+	}
+	s.mu.Lock()
+	session, ok := s.sessions[id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, http.StatusNotFound
+	}
+	if s.expired(session) {
+		delete(s.sessions, id)
+		s.mu.Unlock()
+		log.Printf("ending session %s after %s without requests", id, s.idleTimeout)
+		s.releaseSession(id)
+		return nil, http.StatusNotFound
+	}
+	session.lastUsed.Store(s.now().UnixNano())
+	s.mu.Unlock()
+	return session, http.StatusOK
+}
+
+// endSession forgets a session so later requests carrying its ID get 404
+func (s *StreamableHTTPServer) endSession(id string) {
+	s.mu.Lock()
+	delete(s.sessions, id)
+	s.mu.Unlock()
+	s.releaseSession(id)
+}
+
+// releaseSession unregisters a forgotten session from the MCP server and drops its subscriptions
+func (s *StreamableHTTPServer) releaseSession(id string) {
+	s.server.UnregisterSession(id)
+	if s.subscriptions != nil {
+		s.subscriptions.EndSession(id)
+	}
+}
+
+// isInitialize reports whether a JSON-RPC message is an initialize request
+func isInitialize(message json.RawMessage) bool {
+	var header struct {
+		Method string `json:"method"`
+	}
+	return json.Unmarshal(message, &header) == nil && header.Method == string(mcp.MethodInitialize)
+}
+
+// writeJSONRPCError answers a message that could not be processed at all
+func writeJSONRPCError(w http.ResponseWriter, status, code int, message string) {
+	response := mcp.JSONRPCError{JSONRPC: mcp.JSONRPC_VERSION}
+	response.Error.Code = code
+	response.Error.Message = message
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
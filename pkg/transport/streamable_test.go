@@ -0,0 +1,110 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// endedSessions records the sessions whose subscriptions were dropped
+type endedSessions []string
+
+func (e *endedSessions) Subscribe(context.Context, string, string, Notify) error { return nil }
+func (e *endedSessions) Unsubscribe(string, string)                              {}
+func (e *endedSessions) EndSession(sessionID string)                             { *e = append(*e, sessionID) }
+
+func TestStreamableSessionEviction(t *testing.T) {
+	now := time.Unix(1740819600, 0)
+	var ended endedSessions
+	s := NewStreamableHTTPServer(server.NewMCPServer("test", "1.0.0"), &ended)
+	t.Cleanup(s.Close)
+	s.idleTimeout = time.Hour
+	s.maxSessions = 3
+	s.now = func() time.Time { return now }
+
+	post := func(sessionID, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+		if sessionID != "" {
+			req.Header.Set(sessionHeader, sessionID)
+		}
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		return w
+	}
+	initialize := func() string {
+		t.Helper()
+		w := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+		if w.Code != http.StatusOK || w.Header().Get(sessionHeader) == "" {
+			t.Fatalf("initialize answered %d without a session: %s", w.Code, w.Body)
+		}
+		return w.Header().Get(sessionHeader)
+	}
+	ping := func(sessionID string) int {
+		t.Helper()
+		return post(sessionID, `{"jsonrpc":"2.0","id":2,"method":"ping"}`).Code
+	}
+
+	idle := initialize()
+	now = now.Add(45 * time.Minute)
+	active, streaming := initialize(), initialize()
+	s.sessions[streaming].streaming.Store(true)
+
+	// the idle session is ended once it passes the timeout; the others were used since
+	now = now.Add(30 * time.Minute)
+	if code := ping(active); code != http.StatusOK {
+		t.Fatalf("ping answered %d", code)
+	}
+	first := initialize()
+	if code := ping(idle); code != http.StatusNotFound {
+		t.Errorf("idle session answered %d, want 404", code)
+	}
+
+	// at the cap the least recently used session without a stream makes room
+	now = now.Add(time.Minute)
+	ping(first)
+	last := initialize()
+	if code := ping(active); code != http.StatusNotFound {
+		t.Errorf("least recently used session answered %d, want 404", code)
+	}
+	for _, id := range []string{streaming, first} {
+		if code := ping(id); code != http.StatusOK {
+			t.Errorf("session %s answered %d, want 200", id, code)
+		}
+	}
+
+	// evicted sessions are released like ended ones
+	if len(ended) != 2 || !slices.Contains(ended, idle) || !slices.Contains(ended, active) {
+		t.Errorf("subscriptions ended for %v, want %s and %s", ended, idle, active)
+	}
+	for _, id := range []string{idle, active} {
+		if err := s.server.RegisterSession(&clientSession{id: id}); err != nil {
+			t.Errorf("evicted session is still registered: %v", err)
+		}
+	}
+
+	// a request on an expired session ends it without waiting for a sweep or a new session
+	now = now.Add(2 * time.Hour)
+	if code := ping(first); code != http.StatusNotFound {
+		t.Errorf("expired session answered %d, want 404", code)
+	}
+	if len(ended) != 3 || ended[2] != first {
+		t.Errorf("subscriptions ended for %v, want %s last", ended, first)
+	}
+	if code := ping(streaming); code != http.StatusOK {
+		t.Errorf("session with a stream answered %d after 2h, want 200", code)
+	}
+	// the sweep ends the sessions nobody comes back to
+	s.mu.Lock()
+	expired := s.expireSessions()
+	s.mu.Unlock()
+	if len(expired) != 1 || expired[0] != last {
+		t.Errorf("sweep ended %v, want %s", expired, last)
+	}
+}
@@ -0,0 +1,126 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	methodSubscribe   = "resources/subscribe"
+	methodUnsubscribe = "resources/unsubscribe"
+)
+
+// errNotificationsBlocked is returned when a session's notification queue is full
+var errNotificationsBlocked = errors.New("notification queue full")
+
+// Notify delivers a notification to one session; an error means the session stopped
+// receiving notifications
+type Notify func(notification mcp.JSONRPCNotification) error
+
+// Subscriptions implements resources/subscribe and resources/unsubscribe, which mcp-go
+// answers with "method not found". Updates are sent through the subscriber's Notify.
+type Subscriptions interface {
+	// Subscribe starts sending notifications/resources/updated for uri to the session
+	Subscribe(ctx context.Context, sessionID, uri string, notify Notify) error
+	// Unsubscribe stops the session's notifications for uri
+	Unsubscribe(sessionID, uri string)
+	// EndSession drops every subscription of a session that ended
+	EndSession(sessionID string)
+}
+
+// subscriptionRequest holds the fields of a resources/subscribe or resources/unsubscribe request
+type subscriptionRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params struct {
+		URI string `json:"uri"`
+	} `json:"params"`
+}
+
+// handleSubscription answers message when it is a resources/subscribe or resources/unsubscribe
+// request, reporting whether it was one. Without subscriptions mcp-go handles every message.
+func handleSubscription(ctx context.Context, subscriptions Subscriptions, sessionID string, notify Notify, message json.RawMessage) (mcp.JSONRPCMessage, bool) {
+	var request subscriptionRequest
+	if subscriptions == nil || json.Unmarshal(message, &request) != nil ||
+		(request.Method != methodSubscribe && request.Method != methodUnsubscribe) {
+		return nil, false
+	}
+	if len(request.ID) == 0 {
+		// a notification gets no response
+		return nil, true
+	}
+
+	fail := func(code int, message string) (mcp.JSONRPCMessage, bool) {
+		response := mcp.JSONRPCError{JSONRPC: mcp.JSONRPC_VERSION, ID: request.ID}
+		response.Error.Code = code
+		response.Error.Message = message
+		return response, true
+	}
+	if request.Params.URI == "" {
+		return fail(mcp.INVALID_PARAMS, "uri is required")
+	}
+	if request.Method == methodSubscribe {
+		if err := subscriptions.Subscribe(ctx, sessionID, request.Params.URI, notify); err != nil {
+			return fail(mcp.INVALID_PARAMS, err.Error())
+		}
+	} else {
+		subscriptions.Unsubscribe(sessionID, request.Params.URI)
+	}
+	return mcp.JSONRPCResponse{JSONRPC: mcp.JSONRPC_VERSION, ID: request.ID, Result: mcp.EmptyResult{}}, true
+}
+
+// notify queues a notification for the session without blocking
+func (s *clientSession) notify(notification mcp.JSONRPCNotification) error {
+	select {
+	case s.notifications <- notification:
+		return nil
+	default:
+		return errNotificationsBlocked
+	}
+}
+
+// withSubscriptions answers the subscription requests POSTed to the SSE message endpoint,
+// sending the response over the session's event stream like mcp-go does for other requests
+func withSubscriptions(subscriptions Subscriptions, sseServer *server.SSEServer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subscriptions == nil || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxMessageBytes+1))
+		if err != nil || len(body) > maxMessageBytes {
+			writeJSONRPCError(w, http.StatusBadRequest, mcp.PARSE_ERROR, "failed to read request body")
+			return
+		}
+
+		sessionID := r.URL.Query().Get("sessionId")
+		notify := func(notification mcp.JSONRPCNotification) error {
+			return sseServer.SendEventToSession(sessionID, notification)
+		}
+		response, ok := handleSubscription(r.Context(), subscriptions, sessionID, notify, body)
+		if !ok {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+			return
+		}
+		if response != nil {
+			if err := sseServer.SendEventToSession(sessionID, response); err != nil {
+				subscriptions.EndSession(sessionID)
+				writeJSONRPCError(w, http.StatusBadRequest, mcp.INVALID_PARAMS, "Invalid session ID")
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		if response != nil {
+			json.NewEncoder(w).Encode(response)
+		}
+	})
+}
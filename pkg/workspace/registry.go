@@ -0,0 +1,198 @@
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/shawnzhang/slack-go/pkg/slack"
+)
+
+// ErrUnknownWorkspace is returned for a workspace name that matches no configured alias or team
+var ErrUnknownWorkspace = errors.New("unknown workspace")
+
+// DefaultAlias names the workspace configured through SLACK_TOKEN and SLACK_TEAM_ID
+const DefaultAlias = "default"
+
+// Config is the workspaces file: the tokens the server can use, keyed by an alias tools refer to
+//
+//	{
+//	  "default": "acme",
+//	  "workspaces": [
+//	    {"alias": "acme", "team_id": "T0123ABCDEF", "token_env": "ACME_SLACK_TOKEN"},
+//	    {"alias": "grid", "team_id": "E0123ABCDEF", "token": "xoxp-..."}
+//	  ]
+//	}
+type Config struct {
+	// Default is the alias used when a tool call names no workspace; the first workspace when empty
+	Default    string            `json:"default"`
+	Workspaces []WorkspaceConfig `json:"workspaces"`
+}
+
+// WorkspaceConfig declares one token and the team it must belong to
+type WorkspaceConfig struct {
+	Alias string `json:"alias"`
+	// TeamID is the workspace (T...) or, for org-wide Enterprise Grid tokens, the organization (E...)
+	TeamID string `json:"team_id"`
+	// Token is the token itself; TokenEnv names an environment variable holding it instead
+	Token    string `json:"token,omitempty"`
+	TokenEnv string `json:"token_env,omitempty"`
+	// APIURL is the Web API base URL, e.g. https://slack-gov.com/api/; https://slack.com/api/ when empty
+	APIURL string `json:"api_url,omitempty"`
+}
+
+// LoadConfig reads a workspaces file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspaces file: %w", err)
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid workspaces file %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// Workspace is a configured token together with the identity auth.test reported for it
+type Workspace struct {
+	Alias        string `json:"alias"`
+	TeamID       string `json:"team_id"`
+	TeamName     string `json:"team_name"`
+	EnterpriseID string `json:"enterprise_id,omitempty"`
+	URL          string `json:"url"`
+	// User is the user the token acts as; for bot tokens, the bot user
+	User      string          `json:"user"`
+	UserID    string          `json:"user_id"`
+	TokenType slack.TokenType `json:"token_type"`
+	Default   bool            `json:"default"`
+
+	Client *slack.Client `json:"-"`
+	// Identity is the full auth.test result, including the granted scopes
+	Identity *slack.Identity `json:"-"`
+}
+
+// Registry holds the workspaces the server can act in
+type Registry struct {
+	workspaces   map[string]*Workspace
+	defaultAlias string
+}
+
+// NewRegistry builds a client per configured workspace and checks with auth.test that
+// each token is valid and belongs to its declared team
+func NewRegistry(ctx context.Context, config *Config) (*Registry, error) {
+	if len(config.Workspaces) == 0 {
+		return nil, fmt.Errorf("no workspaces configured")
+	}
+
+	r := &Registry{
+		workspaces:   make(map[string]*Workspace, len(config.Workspaces)),
+		defaultAlias: config.Default,
+	}
+	for i, wc := range config.Workspaces {
+		if wc.Alias == "" {
+			return nil, fmt.Errorf("workspaces[%d]: alias is required", i)
+		}
+		if _, ok := r.workspaces[wc.Alias]; ok {
+			return nil, fmt.Errorf("workspaces[%d]: alias %q is used more than once", i, wc.Alias)
+		}
+		if wc.TeamID == "" {
+			return nil, fmt.Errorf("workspace %s: team_id is required", wc.Alias)
+		}
+		token := wc.Token
+		if wc.TokenEnv != "" {
+			token = os.Getenv(wc.TokenEnv)
+		}
+		if token == "" {
+			return nil, fmt.Errorf("workspace %s: token is empty; set token or the variable named by token_env", wc.Alias)
+		}
+
+		var options []slack.Option
+		if wc.APIURL != "" {
+			options = append(options, slack.OptionAPIURL(wc.APIURL))
+		}
+		ws, err := verify(ctx, wc.Alias, wc.TeamID, slack.NewClient(token, options...))
+		if err != nil {
+			return nil, err
+		}
+		r.workspaces[wc.Alias] = ws
+	}
+
+	if r.defaultAlias == "" {
+		r.defaultAlias = config.Workspaces[0].Alias
+	}
+	def, ok := r.workspaces[r.defaultAlias]
+	if !ok {
+		return nil, fmt.Errorf("default workspace %q is not configured", r.defaultAlias)
+	}
+	def.Default = true
+	return r, nil
+}
+
+// verify runs auth.test and fails unless the token belongs to teamID, which may name
+// the workspace or, for an org-wide token, the Enterprise Grid organization
+func verify(ctx context.Context, alias, teamID string, client *slack.Client) (*Workspace, error) {
+	identity, err := client.AuthTest(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("workspace %s: auth.test failed: %w", alias, err)
+	}
+	if identity.TeamID != teamID && identity.EnterpriseID != teamID {
+		belongsTo := identity.TeamID
+		if identity.EnterpriseID != "" {
+			belongsTo += " (organization " + identity.EnterpriseID + ")"
+		}
+		return nil, fmt.Errorf("workspace %s: token belongs to team %s, not the declared %s", alias, belongsTo, teamID)
+	}
+	return &Workspace{
+		Alias:        alias,
+		TeamID:       identity.TeamID,
+		TeamName:     identity.Team,
+		EnterpriseID: identity.EnterpriseID,
+		URL:          identity.URL,
+		User:         identity.User,
+		UserID:       identity.UserID,
+		TokenType:    identity.TokenType,
+		Client:       client,
+		Identity:     identity,
+	}, nil
+}
+
+// Get returns the workspace with the given alias or team ID; empty selects the default workspace
+func (r *Registry) Get(name string) (*Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return r.workspaces[r.defaultAlias], nil
+	}
+	if ws, ok := r.workspaces[name]; ok {
+		return ws, nil
+	}
+	for _, ws := range r.workspaces {
+		if ws.TeamID == name || (ws.EnterpriseID == name && ws.EnterpriseID != "") {
+			return ws, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %q; configured workspaces: %s", ErrUnknownWorkspace, name, strings.Join(r.Aliases(), ", "))
+}
+
+// List returns every workspace, sorted by alias
+func (r *Registry) List() []*Workspace {
+	list := make([]*Workspace, 0, len(r.workspaces))
+	for _, alias := range r.Aliases() {
+		list = append(list, r.workspaces[alias])
+	}
+	return list
+}
+
+// Aliases returns the configured aliases in sorted order
+func (r *Registry) Aliases() []string {
+	aliases := make([]string, 0, len(r.workspaces))
+	for alias := range r.workspaces {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return aliases
+}
@@ -0,0 +1,90 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shawnzhang/slack-go/pkg/fakeslack"
+)
+
+func TestNewRegistry(t *testing.T) {
+	fake := fakeslack.NewServer()
+	defer fake.Close()
+	fake.SetEnterpriseID("E0FAKE001")
+	ctx := context.Background()
+	bot := func(alias, teamID string) WorkspaceConfig {
+		return WorkspaceConfig{Alias: alias, TeamID: teamID, Token: fakeslack.BotToken, APIURL: fake.URL}
+	}
+
+	if _, err := NewRegistry(ctx, &Config{Workspaces: []WorkspaceConfig{
+		{Alias: "user", TeamID: fakeslack.TeamID, TokenEnv: "TEST_SLACK_USER_TOKEN", APIURL: fake.URL},
+	}}); err == nil || !strings.Contains(err.Error(), "workspace user: token is empty") {
+		t.Errorf("NewRegistry with an unset token_env = %v", err)
+	}
+	t.Setenv("TEST_SLACK_USER_TOKEN", fakeslack.UserToken)
+	registry, err := NewRegistry(ctx, &Config{
+		Default: "user",
+		Workspaces: []WorkspaceConfig{
+			bot("bot", fakeslack.TeamID),
+			bot("grid", "E0FAKE001"),
+			{Alias: "user", TeamID: fakeslack.TeamID, TokenEnv: "TEST_SLACK_USER_TOKEN", APIURL: fake.URL},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grid, err := registry.Get("grid")
+	if err != nil || grid.TeamID != fakeslack.TeamID || grid.EnterpriseID != "E0FAKE001" || grid.UserID != fakeslack.BotUserID {
+		t.Errorf("Get(grid) = %+v, %v", grid, err)
+	}
+	for name, want := range map[string]string{"": "user", " bot ": "bot", "user": "user"} {
+		if ws, err := registry.Get(name); err != nil || ws.Alias != want {
+			t.Errorf("Get(%q) = %v, %v; want %s", name, ws, err, want)
+		}
+	}
+	// every token is in the fake team, so team and organization IDs select any of them
+	if ws, err := registry.Get(fakeslack.TeamID); err != nil || ws.TeamID != fakeslack.TeamID {
+		t.Errorf("Get(%s) = %v, %v", fakeslack.TeamID, ws, err)
+	}
+	if ws, err := registry.Get("E0FAKE001"); err != nil || ws.EnterpriseID != "E0FAKE001" {
+		t.Errorf("Get(E0FAKE001) = %v, %v", ws, err)
+	}
+	if _, err := registry.Get("acme"); !errors.Is(err, ErrUnknownWorkspace) || !strings.Contains(err.Error(), "bot, grid, user") {
+		t.Errorf("Get(acme) = %v, want ErrUnknownWorkspace listing the aliases", err)
+	}
+	for _, ws := range registry.List() {
+		if ws.Default != (ws.Alias == "user") {
+			t.Errorf("%s.Default = %v", ws.Alias, ws.Default)
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		config Config
+		err    string
+	}{
+		"no workspaces":   {Config{}, "no workspaces configured"},
+		"no alias":        {Config{Workspaces: []WorkspaceConfig{bot("", fakeslack.TeamID)}}, "workspaces[0]: alias is required"},
+		"duplicate alias": {Config{Workspaces: []WorkspaceConfig{bot("bot", fakeslack.TeamID), bot("bot", fakeslack.TeamID)}}, `workspaces[1]: alias "bot" is used more than once`},
+		"no team":         {Config{Workspaces: []WorkspaceConfig{bot("bot", "")}}, "workspace bot: team_id is required"},
+		"other team": {
+			Config{Workspaces: []WorkspaceConfig{bot("bot", "T0OTHER01")}},
+			"workspace bot: token belongs to team T0FAKE001 (organization E0FAKE001), not the declared T0OTHER01",
+		},
+		"other organization": {
+			Config{Workspaces: []WorkspaceConfig{bot("grid", "E0OTHER01")}},
+			"workspace grid: token belongs to team T0FAKE001 (organization E0FAKE001), not the declared E0OTHER01",
+		},
+		"unknown default": {Config{Default: "acme", Workspaces: []WorkspaceConfig{bot("bot", fakeslack.TeamID)}}, `default workspace "acme" is not configured`},
+		"bad token": {
+			Config{Workspaces: []WorkspaceConfig{{Alias: "bot", TeamID: fakeslack.TeamID, Token: "xoxb-revoked", APIURL: fake.URL}}},
+			"workspace bot: auth.test failed",
+		},
+	} {
+		if _, err := NewRegistry(ctx, &tc.config); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: NewRegistry = %v, want an error containing %q", name, err, tc.err)
+		}
+	}
+}
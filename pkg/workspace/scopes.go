@@ -0,0 +1,50 @@
+package workspace
+
+import (
+	"slices"
+	"strings"
+)
+
+// Requirement is an OAuth scope a tool needs, given as alternatives: any one of them satisfies it
+// (e.g. channels:history or groups:history for reading a conversation)
+type Requirement []string
+
+// String lists the alternatives, e.g. "channels:history or groups:history"
+func (r Requirement) String() string {
+	return strings.Join(r, " or ")
+}
+
+// satisfiedBy reports whether scopes include one of the alternatives
+func (r Requirement) satisfiedBy(scopes []string) bool {
+	for _, scope := range r {
+		if slices.Contains(scopes, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// Missing returns the requirements the workspace's token does not satisfy. When Slack did not
+// report the token's scopes nothing is considered missing, and calls fail at the API instead.
+func (w *Workspace) Missing(requirements []Requirement) []Requirement {
+	if w.Identity == nil || !w.Identity.ScopesKnown {
+		return nil
+	}
+	var missing []Requirement
+	for _, requirement := range requirements {
+		if !requirement.satisfiedBy(w.Identity.Scopes) {
+			missing = append(missing, requirement)
+		}
+	}
+	return missing
+}
+
+// AnyGrants reports whether at least one workspace satisfies all the requirements
+func (r *Registry) AnyGrants(requirements []Requirement) bool {
+	for _, ws := range r.workspaces {
+		if len(ws.Missing(requirements)) == 0 {
+			return true
+		}
+	}
+	return false
+}